@@ -0,0 +1,46 @@
+package main
+
+import (
+	"Interior_Visualization_Shop/app/pkg/config"
+	"flag"
+	"fmt"
+	"os"
+)
+
+/// Функция runConfigCommand выполняет подкоманду config: \\\
+/// config print [--redact] выводит действующую конфигурацию с учетом окружения и режима, config check только проверяет ее. \\\
+/// Возвращает код завершения процесса: 1, если конфигурация с ошибками \\\
+
+func runConfigCommand(configPath, dotenvPath string, args []string) int {
+	if len(args) == 0 || (args[0] != "print" && args[0] != "check") {
+		fmt.Fprintln(os.Stderr, "usage: app [-config-path config.yml] config print [--redact] | config check")
+		return 2
+	}
+
+	flags := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	redact := flags.Bool("redact", false, "replace passwords, keys and the database DSN with [REDACTED]")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Read(configPath, dotenvPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if args[0] == "print" {
+		out, err := cfg.YAML(*redact)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		os.Stdout.Write(out)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -1,15 +1,19 @@
 package main
 
 import (
+	"Interior_Visualization_Shop/app/internal/normalize"
 	"Interior_Visualization_Shop/app/internal/server"
 	"Interior_Visualization_Shop/app/pkg/config"
 	"Interior_Visualization_Shop/app/pkg/logger"
 	storage "Interior_Visualization_Shop/app/pkg/storage"
+	"Interior_Visualization_Shop/app/pkg/tracing"
 	"context"
 	"errors"
 	"flag"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/julienschmidt/httprouter"
-	"golang.org/x/exp/slog"
+	"github.com/pkg/browser"
+	"github.com/sirupsen/logrus"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,18 +22,59 @@ import (
 )
 
 func main() {
+	configPath := flag.String("config-path", "config.yml", "path for application configuration file")
+	dotenvPath := flag.String("dotenv-path", ".env", "optional file with environment variables")
+	flag.Parse()
+
+	/// подкоманда config печатает или проверяет конфигурацию и не запускает сервер \\\
+	if flag.Arg(0) == "config" {
+		os.Exit(runConfigCommand(*configPath, *dotenvPath, flag.Args()[1:]))
+	}
+
 	log := logger.GetLogger()
 	log.Info("logger initialized")
 
-	configPath := flag.String("config-path", "config.yml", "path for application configuration file")
-	cfg := config.GetConfig(*configPath, ".env")
-	log.Info("loaded config file")
+	cfg := config.GetConfig(*configPath, *dotenvPath)
+	if err := logger.Configure(logger.Options{
+		Level:      cfg.Logger.Level,
+		Format:     cfg.Logger.Format,
+		File:       cfg.Logger.File,
+		MaxSize:    cfg.Logger.MaxSize,
+		MaxAge:     cfg.Logger.MaxAge,
+		MaxBackups: cfg.Logger.MaxBackups,
+		Compress:   cfg.Logger.Compress,
+	}); err != nil {
+		log.WithError(err).Fatal("cannot configure logger")
+	}
+	log.WithFields(logrus.Fields(cfg.Summary())).Info("loaded config file")
 
-	dbConn, err := storage.ConnectDB(*cfg)
+	if err := normalize.Configure(normalize.Options{
+		DefaultRegion:  cfg.Normalize.DefaultRegion,
+		LowercaseEmail: cfg.Normalize.LowercaseEmail,
+	}); err != nil {
+		log.WithError(err).Fatal("cannot configure normalization")
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
 	if err != nil {
-		log.Error("cannot connect to database", err)
+		log.WithError(err).Fatal("cannot initialize tracing")
+	}
+	log.WithField("exporter", cfg.Tracing.Exporter).Info("initialized tracing")
+
+	var dbPool *pgxpool.Pool
+	if cfg.Storage.Type != config.StorageMemory {
+		dbPool, err = storage.ConnectDB(*cfg)
+		if err != nil {
+			log.WithError(err).Fatal("cannot connect to database")
+		}
+		log.Info("connected to database")
 	}
-	log.Info("connected to database")
 
 	router := httprouter.New()
 	log.Info("initialized httprouter")
@@ -38,34 +83,67 @@ func main() {
 	log.Info("starting the server")
 
 	quit := make(chan os.Signal, 1)
-	signals := []os.Signal{syscall.SIGABRT, syscall.SIGQUIT, syscall.SIGHUP, os.Interrupt, syscall.SIGTERM}
+	signals := []os.Signal{syscall.SIGABRT, syscall.SIGQUIT, os.Interrupt, syscall.SIGTERM}
 	signal.Notify(quit, signals...)
 
+	/// SIGHUP не завершает работу, а переоткрывает файл логов после внешней ротации \\\
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 	go func() {
-		if err = srv.Run(dbConn); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Error("cannot run the server", err)
+		for range hup {
+			if err := logger.Reopen(); err != nil {
+				log.WithError(err).Error("cannot reopen log file")
+				continue
+			}
+			log.Info("reopened log file")
+		}
+	}()
+
+	go func() {
+		if err = srv.Run(dbPool); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Error("cannot run the server")
+		}
+	}()
+
+	/// в режиме dev главная страница открывается в браузере, когда сервер готов. \\\
+	/// Без браузера (сервер, контейнер) это только предупреждение в логе \\\
+	go func() {
+		<-srv.Ready()
+		log.WithFields(logrus.Fields{"host": cfg.HTTP.Host, "port": cfg.HTTP.Port}).Info("server has been started")
+		if !cfg.App.OpenBrowser {
+			return
+		}
+		if err := browser.OpenURL(srv.URL()); err != nil {
+			log.WithError(err).Warn("cannot open the browser")
 		}
 	}()
-	log.Info("server has been started ", slog.String("host", cfg.HTTP.Host), slog.String("port", cfg.HTTP.Port))
 
 	<-quit
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer func() {
-		dbCloseCtx, dbCloseCancel := context.WithTimeout(
-			context.Background(),
-			time.Duration(cfg.PostgreSQL.ShutdownTimeout)*time.Second,
-		)
-		defer dbCloseCancel()
-		err = dbConn.Close(dbCloseCtx)
-		if err != nil {
-			log.Error("failed to close database connection:", err)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.WithError(err).Error("failed to flush traces")
+		}
+		if dbPool == nil {
+			return
+		}
+		/// пул закрывается, когда вернутся все соединения, поэтому ожидание ограничено ShutdownTimeout \\\
+		closed := make(chan struct{})
+		go func() {
+			dbPool.Close()
+			close(closed)
+		}()
+		select {
+		case <-closed:
+			log.Info("closed database connection")
+		case <-time.After(time.Duration(cfg.PostgreSQL.ShutdownTimeout) * time.Second):
+			log.Error("failed to close database connection: timed out")
 		}
-		log.Info("closed database connection")
-		cancel()
 	}()
 
 	if err = srv.Shutdown(ctx); err != nil {
-		log.Error("server shutdown failed:", err)
+		log.WithError(err).Error("server shutdown failed")
 	}
 	log.Info("server has been shutted down")
 }
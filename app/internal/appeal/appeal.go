@@ -1,11 +1,17 @@
 package appeal
 
+import (
+	"Interior_Visualization_Shop/app/internal/normalize"
+	"Interior_Visualization_Shop/app/internal/validate"
+	"Interior_Visualization_Shop/app/pkg/logger"
+)
+
 /// Структура для создания обращений \\\
 
 type Appeal struct {
 	ID          int64   `json:"id" example:"1567"`
-	Email       string  `json:"email" example:"petrovmaksim1992@mail.ru"`
-	PhoneNumber string  `json:"phone_number" example:"89656879175"`
+	Email       string  `json:"email" example:"petrovmaksim1992@mail.ru" log:"email"`
+	PhoneNumber string  `json:"phone_number" example:"+79656879175"`
 	Nickname    string  `json:"nickname" example:"Petrov Maksim"`
 	Subject     *string `json:"subject" example:"Service"`
 	Message     string  `json:"message" example:"-"`
@@ -13,10 +19,43 @@ type Appeal struct {
 }
 
 type CreateAppealDTO struct {
-	Email       string  `json:"email" example:"petrovmaksim1992@mail.ru"`
-	PhoneNumber string  `json:"phone_number" example:"89656879175"`
+	Email       string  `json:"email" example:"petrovmaksim1992@mail.ru" log:"email"`
+	PhoneNumber string  `json:"phone_number" example:"+79656879175"`
 	Nickname    string  `json:"nickname" example:"Petrov Maksim"`
 	Subject     *string `json:"subject" example:"Service"`
 	Message     string  `json:"message" example:"-"`
 	Document    *string `json:"document" example:"-"`
 }
+
+/// Функция Normalize приводит адрес почты и номер телефона к каноническому виду. \\\
+/// Нераспознанный номер остается как есть, его отклонит Validate \\\
+
+func (a *CreateAppealDTO) Normalize() {
+	a.Email = normalize.Email(a.Email)
+	if phone, err := normalize.Phone(a.PhoneNumber); err == nil {
+		a.PhoneNumber = phone
+	}
+}
+
+/// Функция Validate проверяет поля формы обращения, имена ошибок совпадают с полями формы \\\
+
+func (a *CreateAppealDTO) Validate() error {
+	var v validate.Errors
+	v.Email("email", a.Email)
+	v.Phone("phonenumber", a.PhoneNumber)
+	v.Length("nickname", a.Nickname, 1, 100)
+	v.SingleLine("nickname", a.Nickname)
+	if a.Subject != nil {
+		v.Length("subject", *a.Subject, 0, 200)
+		v.SingleLine("subject", *a.Subject)
+	}
+	v.Length("message", a.Message, 1, 5000)
+	return v.Err()
+}
+
+/// Методы String и GoString скрывают адреса почты при выводе структур в лог \\\
+
+func (a Appeal) String() string            { return logger.Redacted(a) }
+func (a Appeal) GoString() string          { return logger.Redacted(a) }
+func (a CreateAppealDTO) String() string   { return logger.Redacted(a) }
+func (a CreateAppealDTO) GoString() string { return logger.Redacted(a) }
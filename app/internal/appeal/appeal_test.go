@@ -0,0 +1,24 @@
+package appeal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDTOsDoNotLeakEmails(t *testing.T) {
+	const email = "petrovmaksim1992@mail.ru"
+	subject, document := "Service", "./appealdocuments/"+email+"plan.pdf"
+
+	dtos := []interface{}{
+		Appeal{ID: 1, Email: email, PhoneNumber: "89656879175", Nickname: "Petrov Maksim", Subject: &subject, Message: "-", Document: &document},
+		&CreateAppealDTO{Email: email, PhoneNumber: "89656879175", Nickname: "Petrov Maksim", Subject: &subject, Message: "-", Document: &document},
+	}
+	for _, dto := range dtos {
+		for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+			if out := fmt.Sprintf(verb, dto); strings.Contains(out, email) {
+				t.Errorf("fmt.Sprintf(%q, %T) leaks %q: %s", verb, dto, email, out)
+			}
+		}
+	}
+}
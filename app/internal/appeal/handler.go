@@ -2,9 +2,9 @@ package appeal
 
 import (
 	"Interior_Visualization_Shop/app/internal/apperror"
-	"Interior_Visualization_Shop/app/internal/auth"
 	"Interior_Visualization_Shop/app/internal/handler"
 	"Interior_Visualization_Shop/app/internal/mail"
+	"Interior_Visualization_Shop/app/internal/middleware/auth"
 	"Interior_Visualization_Shop/app/internal/response"
 	"Interior_Visualization_Shop/app/pkg/config"
 	"Interior_Visualization_Shop/app/pkg/logger"
@@ -14,11 +14,14 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
 const (
 	appealURL = "/protected/appeal"
+	/// Часть формы, которая держится в памяти, остальное http пишет во временные файлы \\\
+	maxFormMemory = 32 << 20
 )
 
 /// Структура Handler представляющая собой обработчик объекта appealService для обращений \\\
@@ -27,93 +30,56 @@ type Handler struct {
 	log           logger.Logger
 	appealService Service
 	cfg           config.Config
-	authService   auth.Service
+	authn         *auth.Authenticator
+	mailSender    mail.Sender
 }
 
 /// Структура NewHandler возвращает новый экземпляр Handler инициализируя переданные в него аргументы \\\
 
-func NewHandler(log logger.Logger, appealService Service, cfg config.Config, authService auth.Service) handler.Hand {
+func NewHandler(log logger.Logger, appealService Service, cfg config.Config, authn *auth.Authenticator, mailSender mail.Sender) handler.Hand {
 	return &Handler{
 		log:           log,
 		appealService: appealService,
 		cfg:           cfg,
-		authService:   authService,
+		authn:         authn,
+		mailSender:    mailSender,
 	}
 }
 
 /// Структура Register регистрирует новые запросы для обращений \\\
 
 func (h *Handler) Register(router *httprouter.Router) {
-	router.HandlerFunc(http.MethodPost, appealURL, h.AuthMiddleware(h.CreateAppeal))
-}
-
-/// Структура AuthMiddleware проверят авторизирован ли пользователь в системе \\\
-
-func (h *Handler) AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		h.log.Info("HANDLER: CHECK AUTH")
-
-		// Извлекаем JWT-токен из заголовка запроса
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			response.ErrorAuth(w, "empty auth header", "")
-			return
-		}
-
-		// Извлекаем строку токена из заголовка
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			response.ErrorAuth(w, "invalid auth header", "")
-			return
-		}
-
-		// Передаем токен
-		token, err := h.authService.ParseToken(tokenString)
-		if err != nil {
-			response.ErrorAuth(w, err.Error(), "")
-			return
-		}
-		r.Header.Set("email", token)
-		next(w, r)
-	}
+	router.HandlerFunc(http.MethodPost, appealURL, h.authn.Required(h.CreateAppeal))
 }
 
 /// Вызов функции CreateAppeal для обработки запроса на создание обращения \\\
 
 func (h *Handler) CreateAppeal(w http.ResponseWriter, r *http.Request) {
-	h.log.Info("HANDLER: CREATE APPEAL")
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: CREATE APPEAL")
 
 	var input CreateAppealDTO
 
-	/// Чтение данных типа form-data входящего запроса r. Email \\\
-	input.Email = strings.TrimSpace(r.FormValue("email"))
-	if input.Email == "" {
-		response.BadRequest(w, "empty email", "")
+	/// Чтение данных типа form-data входящего запроса r, тело больше лимита сервера отклоняется целиком \\\
+	var maxBytesError *http.MaxBytesError
+	if err := r.ParseMultipartForm(maxFormMemory); errors.As(err, &maxBytesError) {
+		response.Error(w, r, apperror.Wrap(err, apperror.KindTooLarge, apperror.ErrRequestTooLarge.Message))
 		return
 	}
-
-	/// Чтение данных типа form-data входящего запроса r. Phonenumber \\\
+	input.Email = strings.TrimSpace(r.FormValue("email"))
 	input.PhoneNumber = strings.TrimSpace(r.FormValue("phonenumber"))
-	if input.PhoneNumber == "" {
-		response.BadRequest(w, "empty phonenumber", "")
-		return
-	}
-	/// Чтение данных типа form-data входящего запроса r. Nickname \\\
 	input.Nickname = strings.TrimSpace(r.FormValue("nickname"))
-	if input.Nickname == "" {
-		response.BadRequest(w, "empty nickname", "")
-		return
-	}
-	/// Чтение данных типа form-data входящего запроса r. Subject \\\
 	subject := strings.TrimSpace(r.FormValue("subject"))
 	if subject == "" {
 		subject = "Feedback form"
 	}
 	input.Subject = &subject
-	/// Чтение данных типа form-data входящего запроса r. Message \\\
 	input.Message = strings.TrimSpace(r.FormValue("message"))
-	if input.Message == "" {
-		response.BadRequest(w, "empty message", "")
+
+	/// Приведение контактов к каноническому виду и проверка всех полей формы, ошибки возвращаются одним ответом \\\
+	input.Normalize()
+	if err := input.Validate(); err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
@@ -125,29 +91,25 @@ func (h *Handler) CreateAppeal(w http.ResponseWriter, r *http.Request) {
 	} else {
 		defer file.Close()
 		/// Создаем путь и новый файл для сохранения документов \\\
-		docPath = "./appealdocuments/" + input.Email + header.Filename
+		docPath = filepath.Join(h.cfg.Blob.Dir, input.Email+filepath.Base(header.Filename))
 		out, err := os.Create(docPath)
 		if err != nil {
-			response.InternalError(w, fmt.Sprintf("error saving document: %v", err), "")
+			response.Error(w, r, fmt.Errorf("error saving document: %w", err))
 			return
 		}
 		defer out.Close()
 		/// Копируем загруженный файл в новый созданный файл на севрере по созданному путю \\\
 		_, err = io.Copy(out, file)
 		if err != nil {
-			response.InternalError(w, fmt.Sprintf("error coping document: %v", err), "")
+			response.Error(w, r, fmt.Errorf("error coping document: %w", err))
 			return
 		}
 	}
-	/// Считываем данные поты отправителя из env файла \\\
-	from := h.cfg.MAIL.MailAddress
-	password := h.cfg.MAIL.MailPassword
-
 	/// Формируем и отправляем письмо пользователю\\\
-	h.log.Info("HANDLER: SENDING MESSAGE")
-	err = mail.SendAppealEmail(from, password, input.Email, input.Nickname, *input.Subject)
+	log.Info("HANDLER: SENDING MESSAGE")
+	err = h.mailSender.SendAppealEmail(r.Context(), input.Email, input.Nickname, *input.Subject)
 	if err != nil {
-		h.log.Errorf("failed to send message: %v", err)
+		log.Errorf("failed to send message: %v", err)
 	}
 
 	a := CreateAppealDTO{
@@ -158,19 +120,15 @@ func (h *Handler) CreateAppeal(w http.ResponseWriter, r *http.Request) {
 		Message:     input.Message,
 		Document:    &docPath,
 	}
-	h.log.Printf("Input: %+v\n", &a)
+	log.Printf("Input: %+v\n", &a)
 
 	/// Вызов функции Create передавая ей полученные значения и ссылку на структуру a \\\
 	appeal, err := h.appealService.Create(r.Context(), &a)
 	if err != nil {
-		if errors.Is(err, apperror.ErrRepeatedEmail) {
-			response.BadRequest(w, err.Error(), "")
-			return
-		}
-		response.InternalError(w, fmt.Sprintf("cannot create user: %v", err), "")
+		response.Error(w, r, fmt.Errorf("cannot create appeal: %w", err))
 		return
 	}
 
-	h.log.Info("APPEAL CREATED")
+	log.Info("APPEAL CREATED")
 	response.JSON(w, http.StatusCreated, appeal)
 }
@@ -0,0 +1,85 @@
+package appeal
+
+import (
+	"Interior_Visualization_Shop/app/internal/apptest"
+	"Interior_Visualization_Shop/app/internal/middleware/auth"
+	"Interior_Visualization_Shop/app/pkg/config"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/token"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCreateAppeal(t *testing.T) {
+	log := logger.GetLogger()
+	var cfg config.Config
+
+	tokens := apptest.AccessTokens()
+	accessToken := apptest.Token(t, tokens, token.User{ID: 1, Email: "petrovmaksim1992@mail.ru"})
+
+	sender := apptest.NewSender()
+	router := httprouter.New()
+	NewHandler(log, NewService(NewMemoryStorage(), log), cfg, auth.New(log, tokens, auth.Options{AccessCookie: "access_token", CSRFCookie: "csrf_token", CSRFHeader: "X-CSRF-Token"}), sender).Register(router)
+
+	form := url.Values{
+		"email":       {"PetrovMaksim1992@Mail.ru"},
+		"phonenumber": {"8 (965) 687-91-75"},
+		"nickname":    {"Petrov Maksim"},
+		"message":     {"I would like to order a visualization"},
+	}
+	post := func(form url.Values, headers ...string) *httptest.ResponseRecorder {
+		return apptest.Serve(router, http.MethodPost, "/protected/appeal", form.Encode(),
+			append([]string{"Content-Type", "application/x-www-form-urlencoded"}, headers...)...)
+	}
+
+	if rec := post(form); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without Authorization: got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := post(form, "Authorization", "Bearer not-a-token"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("with an invalid token: got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	incomplete := url.Values{"email": form["email"], "phonenumber": form["phonenumber"], "nickname": form["nickname"]}
+	if rec := post(incomplete, "Authorization", "Bearer "+accessToken); rec.Code != http.StatusBadRequest {
+		t.Errorf("without a message: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	injected := url.Values{"email": form["email"], "phonenumber": form["phonenumber"], "nickname": form["nickname"], "message": form["message"],
+		"subject": {"Hi\r\nBcc: victim@mail.ru"}}
+	if rec := post(injected, "Authorization", "Bearer "+accessToken); rec.Code != http.StatusBadRequest || len(sender.Appeals()) != 0 {
+		t.Errorf("with a line break in the subject: got status %d and notifications %v, want %d", rec.Code, sender.Appeals(), http.StatusBadRequest)
+	}
+
+	rec := post(form, "Authorization", "Bearer "+accessToken)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /protected/appeal: got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	var created Appeal
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode appeal: %v", err)
+	}
+	if created.ID < 1 || created.Subject == nil || *created.Subject != "Feedback form" {
+		t.Errorf("POST /protected/appeal: got %s", rec.Body)
+	}
+	if created.Email != "petrovmaksim1992@mail.ru" || created.PhoneNumber != "+79656879175" {
+		t.Errorf("POST /protected/appeal: contacts are not normalized: %s", rec.Body)
+	}
+	if sent := sender.Appeals(); len(sent) != 1 || sent[0] != "petrovmaksim1992@mail.ru" {
+		t.Errorf("appeal notifications: got %v", sent)
+	}
+
+	/// Браузер отправляет токен доступа в cookie и должен повторить CSRF cookie в заголовке \\\
+	session := apptest.Cookies(&http.Cookie{Name: "access_token", Value: accessToken}, &http.Cookie{Name: "csrf_token", Value: "csrf-value"})
+	postFromBrowser := func(csrf string) *httptest.ResponseRecorder {
+		return post(form, "Cookie", session, "X-CSRF-Token", csrf)
+	}
+	if rec := postFromBrowser(""); rec.Code != http.StatusForbidden {
+		t.Errorf("cookie without a CSRF token: got status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := postFromBrowser("csrf-value"); rec.Code != http.StatusCreated {
+		t.Errorf("cookie with the CSRF token: got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+}
@@ -0,0 +1,41 @@
+package appeal
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"sync"
+)
+
+var _ Storage = &MemoryStorage{}
+
+/// Структура MemoryStorage хранящая обращения в памяти процесса (режим "memory" для демонстраций и тестов) \\\
+
+type MemoryStorage struct {
+	log     logger.Logger
+	mu      sync.Mutex
+	appeals []Appeal
+}
+
+/// Структура NewMemoryStorage возвращает новый пустой экземпляр MemoryStorage \\\
+
+func NewMemoryStorage() Storage {
+	return &MemoryStorage{
+		log: logger.GetLogger(),
+	}
+}
+
+/// Функция Create для сущности MemoryStorage создает запись обращения в памяти \\\
+
+func (d *MemoryStorage) Create(ctx context.Context, appeal *Appeal) (*Appeal, error) {
+	d.log.FromContext(ctx).Info("MEMORY: CREATE APPEAL")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	appeal.ID = int64(len(d.appeals) + 1)
+	d.appeals = append(d.appeals, *appeal)
+	return appeal, nil
+}
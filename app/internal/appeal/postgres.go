@@ -2,9 +2,11 @@ package appeal
 
 import (
 	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"Interior_Visualization_Shop/app/pkg/tracing"
 	"context"
 	"fmt"
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"time"
 )
 
@@ -14,13 +16,13 @@ var _ Storage = &AppealStorage{}
 
 type AppealStorage struct {
 	log            logger.Logger
-	conn           *pgx.Conn
+	conn           *pgxpool.Pool
 	requestTimeout time.Duration
 }
 
 /// Структура NewStorage возвращает новый экземпляр AppealStorage инициализируя переданные в него аргументы \\\
 
-func NewStorage(storage *pgx.Conn, requestTimeout int) Storage {
+func NewStorage(storage *pgxpool.Pool, requestTimeout int) Storage {
 	return &AppealStorage{
 		log:            logger.GetLogger(),
 		conn:           storage,
@@ -30,14 +32,17 @@ func NewStorage(storage *pgx.Conn, requestTimeout int) Storage {
 
 /// Функция Create для сущности AppealStorage создает записи обращений в БД \\\
 
-func (d *AppealStorage) Create(appeal *Appeal) (*Appeal, error) {
-	d.log.Info("POSTGRES: CREATE APPEAL")
+func (d *AppealStorage) Create(ctx context.Context, appeal *Appeal) (*Appeal, error) {
+	d.log.FromContext(ctx).Info("POSTGRES: CREATE APPEAL")
+	ctx, span := tracing.StartQuery(ctx, "create_appeal")
+	defer span.End()
 
-	/// Ограничение времени выполнения запроса \\\
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	/// Ограничение времени выполнения запроса, не превышающее срок контекста вызывающей стороны \\\
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
 	/// Выполнение запроса к БД \\\
+	start := time.Now()
 	row := d.conn.QueryRow(ctx,
 		`INSERT INTO appeal (email, phone_number, nickname, subject, message, document)
 			 VALUES($1,$2,$3,$4,$5,$6) 
@@ -46,6 +51,8 @@ func (d *AppealStorage) Create(appeal *Appeal) (*Appeal, error) {
 
 	/// Сканирование полученных значений из БД \\\
 	err := row.Scan(&appeal.ID)
+	metrics.ObserveQuery("create_appeal", start, err)
+	tracing.RecordError(span, err)
 	if err != nil {
 		err = fmt.Errorf("failed to execute create appeal query: %v", err)
 		return nil, err
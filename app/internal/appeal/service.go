@@ -2,6 +2,7 @@ package appeal
 
 import (
 	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/tracing"
 	"context"
 )
 
@@ -30,7 +31,10 @@ func NewService(storage Storage, log logger.Logger) Service {
 /// Функция Create создает обращение через интерфейс Service принимая входные данные input \\\
 
 func (s *service) Create(ctx context.Context, input *CreateAppealDTO) (*Appeal, error) {
-	s.log.Info("SERVICE: CREATE APPEAL")
+	ctx, span := tracing.Start(ctx, "appeal.Service.Create")
+	defer span.End()
+	log := s.log.FromContext(ctx)
+	log.Info("SERVICE: CREATE APPEAL")
 
 	/// Создание структуры a на основе полученных данных \\\
 	a := Appeal{
@@ -43,7 +47,7 @@ func (s *service) Create(ctx context.Context, input *CreateAppealDTO) (*Appeal,
 	}
 
 	/// Вызов функции Create в хранилище записей \\\
-	appeal, err := s.storage.Create(&a)
+	appeal, err := s.storage.Create(ctx, &a)
 	if err != nil {
 		return nil, err
 	}
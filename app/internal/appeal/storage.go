@@ -1,5 +1,7 @@
 package appeal
 
+import "context"
+
 type Storage interface {
-	Create(user *Appeal) (*Appeal, error)
+	Create(ctx context.Context, appeal *Appeal) (*Appeal, error)
 }
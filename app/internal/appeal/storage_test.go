@@ -0,0 +1,79 @@
+package appeal
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"testing"
+)
+
+/// Функция testStorage - общий набор проверок, который проходит каждая реализация Storage \\\
+
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+	subject, document := "Service", "without a file"
+
+	first, err := s.Create(ctx, &Appeal{
+		Email:       "conformance@mail.ru",
+		PhoneNumber: "89656879175",
+		Nickname:    "Petrov Maksim",
+		Subject:     &subject,
+		Message:     "first",
+		Document:    &document,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if first.ID < 1 {
+		t.Fatalf("Create: got id %d, want a positive id", first.ID)
+	}
+
+	second, err := s.Create(ctx, &Appeal{
+		Email:       "conformance@mail.ru",
+		PhoneNumber: "89656879175",
+		Nickname:    "Petrov Maksim",
+		Message:     "second",
+	})
+	if err != nil {
+		t.Fatalf("Create second appeal from the same email: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Errorf("Create: got repeated id %d", second.ID)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = s.Create(canceled, &Appeal{Email: "conformance@mail.ru", PhoneNumber: "1", Nickname: "n", Message: "m"}); err == nil {
+		t.Error("Create with a canceled context: got nil error")
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, NewMemoryStorage())
+}
+
+/// Функция TestPostgresStorage прогоняет набор на настоящей базе, если задана TEST_DATABASE_DSN \\\
+
+func TestPostgresStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	cleanup := func() {
+		if _, err := conn.Exec(ctx, `DELETE FROM appeal WHERE email = 'conformance@mail.ru'`); err != nil {
+			t.Fatalf("cleanup: %v", err)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	testStorage(t, NewStorage(conn, 5))
+}
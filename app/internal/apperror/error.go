@@ -2,37 +2,129 @@ package apperror
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 )
 
+/// Виды ошибок приложения. По виду ошибки центральный обработчик выбирает HTTP статус ответа \\\
+
+type Kind string
+
+const (
+	KindInternal        Kind = "internal"
+	KindNotFound        Kind = "not_found"
+	KindConflict        Kind = "conflict"
+	KindValidation      Kind = "validation"
+	KindUnauthorized    Kind = "unauthorized"
+	KindForbidden       Kind = "forbidden"
+	KindTooManyRequests Kind = "too_many_requests"
+	KindTooLarge        Kind = "too_large"
+	KindUnavailable     Kind = "unavailable"
+)
+
+/// Функция Status возвращает HTTP статус для вида ошибки k \\\
+
+func (k Kind) Status() int {
+	switch k {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindTooManyRequests:
+		return http.StatusTooManyRequests
+	case KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 var (
-	ErrNotFound = NewAppError(
-		http.StatusNotFound,
-		"requested resource is not found",
-		"help")
-	ErrEmptyString        = errors.New("empty string")
-	ErrRepeatedEmail      = errors.New("this email is already in use")
-	ErrInvalidRequestBody = errors.New("invalid request body")
-	ErrInvalidMailCode    = errors.New("the entered code is not correct")
+	ErrNotFound           = New(KindNotFound, "requested resource is not found")
+	ErrRepeatedEmail      = New(KindConflict, "this email is already in use")
+	ErrInvalidRequestBody = New(KindValidation, "invalid request body")
+	ErrInvalidMailCode    = New(KindValidation, "the entered code is not correct")
+	ErrMailCodeExpired    = New(KindValidation, "the confirmation code has expired, request a new one")
+	ErrTooManyAttempts    = New(KindValidation, "too many wrong codes, request a new one")
+	ErrInvalidCredentials = New(KindUnauthorized, "invalid email or password")
+	ErrTooManyRequests    = New(KindTooManyRequests, "too many requests, try again later")
+	ErrRequestTooLarge    = New(KindTooLarge, "request body is too large")
+	ErrUnauthorized       = New(KindUnauthorized, "authentication is required")
+	ErrForbidden          = New(KindForbidden, "access to this resource is forbidden")
+	ErrInvalidCSRFToken   = New(KindForbidden, "CSRF token is missing or invalid")
+	ErrUnavailable        = New(KindUnavailable, "service is temporarily unavailable, try again later")
 )
 
+/// Структура FieldError описывает ошибку проверки одного поля запроса \\\
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+/// Структура AppError - ошибка приложения с видом Kind. \\\
+/// Message можно показывать клиенту, причина Err попадает только в лог \\\
+
 type AppError struct {
-	Err              error  `json:"-"`
-	Message          string `json:"message,omitempty"`
-	DeveloperMessage string `json:"developer_message,omitempty"`
-	Code             int    `json:"code,omitempty"`
+	Kind    Kind
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+/// Функция New возвращает ошибку вида kind с сообщением message для клиента \\\
+
+func New(kind Kind, message string) *AppError {
+	return &AppError{
+		Kind:    kind,
+		Message: message,
+	}
+}
+
+/// Функция Wrap возвращает ошибку вида kind с сообщением message, сохраняя причину err \\\
+
+func Wrap(err error, kind Kind, message string) *AppError {
+	return &AppError{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
 }
 
-func NewAppError(code int, developerMessage, message string) *AppError {
+/// Функция Validation возвращает ошибку проверки запроса со списком ошибок полей fields \\\
+
+func Validation(fields ...FieldError) *AppError {
 	return &AppError{
-		Err:              fmt.Errorf(message),
-		Message:          message,
-		DeveloperMessage: developerMessage,
-		Code:             code,
+		Kind:    KindValidation,
+		Message: "request validation failed",
+		Fields:  fields,
 	}
 }
 
 func (e *AppError) Error() string {
-	return e.Err.Error()
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+/// Функция KindOf возвращает вид ошибки err, ошибки без вида считаются внутренними \\\
+
+func KindOf(err error) Kind {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return KindInternal
 }
@@ -0,0 +1,118 @@
+package apptest
+
+import (
+	"Interior_Visualization_Shop/app/pkg/token"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/// Издатель, аудитория и сессия токенов доступа, которые выпускает AccessTokens \\\
+
+const (
+	Issuer    = "shop.test"
+	SessionID = "test-session"
+)
+
+/// Функция AccessTokens возвращает выпуск и проверку токенов доступа HS256 для тестов обработчиков \\\
+
+func AccessTokens() *token.JWT {
+	keys, err := token.NewKeySet(token.HS256, []token.Key{{ID: "1", Material: []byte("test-access-secret")}})
+	if err != nil {
+		panic(err)
+	}
+	return token.New(keys, token.Options{Issuer: Issuer, Audience: Issuer, TTL: 10 * time.Minute})
+}
+
+/// Функция Token выпускает токен доступа пользователя u в сессии SessionID \\\
+
+func Token(t testing.TB, tokens token.Issuer, u token.User) string {
+	t.Helper()
+	raw, err := tokens.Issue(u, SessionID)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return raw
+}
+
+/// Функция Bearer возвращает значение заголовка Authorization с токеном доступа пользователя u \\\
+
+func Bearer(t testing.TB, tokens token.Issuer, u token.User) string {
+	t.Helper()
+	return "Bearer " + Token(t, tokens, u)
+}
+
+/// Функция Serve отправляет обработчику handler запрос method на адрес target с телом body в JSON и возвращает ответ. \\\
+/// headers - пары имя и значение заголовка, заголовки с пустым значением не добавляются \\\
+
+func Serve(handler http.Handler, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+/// Функция Cookies собирает значение заголовка Cookie, которое браузер отправил бы с cookies \\\
+
+func Cookies(cookies ...*http.Cookie) string {
+	pairs := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		pairs = append(pairs, (&http.Cookie{Name: c.Name, Value: c.Value}).String())
+	}
+	return strings.Join(pairs, "; ")
+}
+
+/// Структура Sender - почта без SMTP: отдает коды и ссылки подтверждения в каналы Codes и URLs \\\
+/// и запоминает адресатов уведомлений об обращениях \\\
+
+type Sender struct {
+	Codes chan string
+	URLs  chan string
+
+	mu      sync.Mutex
+	appeals []string
+}
+
+/// Структура NewSender возвращает новый экземпляр Sender, каналы которого вмещают одно письмо \\\
+
+func NewSender() *Sender {
+	return &Sender{
+		Codes: make(chan string, 1),
+		URLs:  make(chan string, 1),
+	}
+}
+
+func (s *Sender) SendEmail(ctx context.Context, addressee, name, surname, confirmCode, confirmURL string) error {
+	s.Codes <- confirmCode
+	s.URLs <- confirmURL
+	return nil
+}
+
+func (s *Sender) SendAppealEmail(ctx context.Context, addressee, fio, mailsubject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appeals = append(s.appeals, addressee)
+	return nil
+}
+
+func (s *Sender) Ping(ctx context.Context) error {
+	return nil
+}
+
+/// Функция Appeals возвращает адресатов отправленных уведомлений об обращениях \\\
+
+func (s *Sender) Appeals() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.appeals...)
+}
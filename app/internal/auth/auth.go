@@ -1,41 +1,99 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"Interior_Visualization_Shop/app/internal/normalize"
+	"Interior_Visualization_Shop/app/internal/validate"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
+)
 
 /// Структура для авторизации и регистрации пользователей \\\
 
-type AccessToken struct {
-	ID      int64  `json:"id" example:"1567"`
-	Email   string `json:"email" example:"petrovmaksim1992@mail.ru"`
-	Name    string `json:"name" example:"Maksim"`
-	Surname string `json:"surname" example:"Petrov"`
-}
-
-type RefreshToken struct {
-	ID int64 `json:"id" example:"1567"`
-}
-
 type AuthByEmail struct {
-	Email    string `json:"email" example:"petrovmaksim1992@mail.ru"`
-	Password string `json:"password" example:"abcdEFG"`
+	Email    string `json:"email" example:"petrovmaksim1992@mail.ru" log:"email"`
+	Password string `json:"password" example:"abcdEFG" log:"secret"`
 }
 
 type AuthResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token" log:"secret"`
+	RefreshToken string `json:"refresh_token" log:"secret"`
 }
 
 type Register struct {
-	Email    string `json:"email" example:"petrovmaksim1992@mail.ru"`
+	Email    string `json:"email" example:"petrovmaksim1992@mail.ru" log:"email"`
 	Name     string `json:"name" example:"Maksim"`
 	Surname  string `json:"surname" example:"Petrov"`
-	Password string `json:"password" example:"sfdsg"`
+	Password string `json:"password" example:"sfdsg" log:"secret"`
+}
+
+type CheckCode struct {
+	Email string `json:"email" example:"petrovmaksim1992@mail.ru" log:"email"`
+	Code  string `json:"code" example:"482913" log:"secret"`
 }
+
+type ConfirmToken struct {
+	Token string `json:"token" log:"secret"`
+}
+
 type RegisterResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token" log:"secret"`
+	RefreshToken string `json:"refresh_token" log:"secret"`
 }
 
+/// Методы String и GoString скрывают пароли, токены и адреса почты при выводе структур в лог \\\
+
+func (a AuthByEmail) String() string        { return logger.Redacted(a) }
+func (a AuthByEmail) GoString() string      { return logger.Redacted(a) }
+func (a AuthResponse) String() string       { return logger.Redacted(a) }
+func (a AuthResponse) GoString() string     { return logger.Redacted(a) }
+func (u Register) String() string           { return logger.Redacted(u) }
+func (u Register) GoString() string         { return logger.Redacted(u) }
+func (c CheckCode) String() string          { return logger.Redacted(c) }
+func (c CheckCode) GoString() string        { return logger.Redacted(c) }
+func (c ConfirmToken) String() string       { return logger.Redacted(c) }
+func (c ConfirmToken) GoString() string     { return logger.Redacted(c) }
+func (r RegisterResponse) String() string   { return logger.Redacted(r) }
+func (r RegisterResponse) GoString() string { return logger.Redacted(r) }
+
+/// Функции Normalize приводят адрес почты к каноническому виду, под которым он хранится \\\
+
+func (a *AuthByEmail) Normalize() { a.Email = normalize.Email(a.Email) }
+func (u *Register) Normalize()    { u.Email = normalize.Email(u.Email) }
+func (c *CheckCode) Normalize()   { c.Email = normalize.Email(c.Email) }
+
+/// Функции Validate проверяют данные запросов входа и регистрации \\\
+
+func (a *AuthByEmail) Validate() error {
+	var v validate.Errors
+	v.Email("email", a.Email)
+	v.Required("password", a.Password)
+	return v.Err()
+}
+
+func (u *Register) Validate() error {
+	var v validate.Errors
+	v.Email("email", u.Email)
+	v.Length("name", u.Name, 1, 100)
+	v.Length("surname", u.Surname, 1, 100)
+	v.Password("password", u.Password)
+	return v.Err()
+}
+
+func (c *CheckCode) Validate() error {
+	var v validate.Errors
+	v.Email("email", c.Email)
+	v.Length("code", c.Code, 1, 64)
+	return v.Err()
+}
+
+func (c *ConfirmToken) Validate() error {
+	var v validate.Errors
+	v.Length("token", c.Token, 1, 128)
+	return v.Err()
+}
+
+/// Функция HashPassword заменяет пароль регистрации его bcrypt хэшем \\\
+
 func (u *Register) HashPassword() error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/pkg/token"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDTOsDoNotLeakSecrets(t *testing.T) {
+	const (
+		email    = "petrovmaksim1992@mail.ru"
+		password = "abcdEFG"
+		access   = "eyJhbGciOiJIUzI1NiJ9.access"
+		refresh  = "eyJhbGciOiJIUzI1NiJ9.refresh"
+	)
+	dtos := []interface{}{
+		token.User{ID: 1, Email: email, Name: "Maksim", Surname: "Petrov"},
+		AuthByEmail{Email: email, Password: password},
+		&AuthByEmail{Email: email, Password: password},
+		AuthResponse{AccessToken: access, RefreshToken: refresh},
+		Register{Email: email, Name: "Maksim", Surname: "Petrov", Password: password},
+		&Register{Email: email, Name: "Maksim", Surname: "Petrov", Password: password},
+		RegisterResponse{AccessToken: access, RefreshToken: refresh},
+	}
+	for _, dto := range dtos {
+		for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+			out := fmt.Sprintf(verb, dto)
+			for _, secret := range []string{email, password, access, refresh} {
+				if strings.Contains(out, secret) {
+					t.Errorf("fmt.Sprintf(%q, %T) leaks %q: %s", verb, dto, secret, out)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	authmw "Interior_Visualization_Shop/app/internal/middleware/auth"
+	"Interior_Visualization_Shop/app/internal/response"
+	"Interior_Visualization_Shop/app/pkg/config"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/// Значение параметра session, которым страница сайта просит положить токены в cookie, а не в тело ответа. \\\
+/// API клиенты параметр не передают и, как раньше, получают токены в JSON \\\
+
+const sessionModeCookie = "cookie"
+
+/// Функция cookieSession сообщает, что ответ на вход нужно отдать в режиме браузера \\\
+
+func (h *Handler) cookieSession(r *http.Request) bool {
+	return h.cfg.Auth.Cookies && r.URL.Query().Get("session") == sessionModeCookie
+}
+
+/// Функция sessionFromCookie сообщает, что запрос пришел от браузера с cookie сессии: как и в middleware auth, \\\
+/// заголовок Authorization важнее cookie. Скрипт чужого сайта такой заголовок в запрос не добавит \\\
+
+func (h *Handler) sessionFromCookie(r *http.Request) bool {
+	if !h.cfg.Auth.Cookies || r.Header.Get("Authorization") != "" {
+		return false
+	}
+	for _, name := range []string{h.cfg.Auth.AccessCookie, h.cfg.Auth.CSRFCookie} {
+		if c, err := r.Cookie(name); err == nil && c.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+/// Функция validCSRF проверяет, что запрос повторил значение CSRF cookie в заголовке \\\
+
+func (h *Handler) validCSRF(r *http.Request) bool {
+	return authmw.ValidCSRF(r, h.cfg.Auth.CSRFCookie, h.cfg.Auth.CSRFHeader)
+}
+
+/// Функция requireCSRF отвечает 403 и возвращает false, если запрос браузера, который получит или изменит \\\
+/// cookie сессии, не несет CSRF токен. Иначе чужой сайт мог бы выйти из сессии пользователя или войти под своим аккаунтом \\\
+
+func (h *Handler) requireCSRF(w http.ResponseWriter, r *http.Request) bool {
+	if h.validCSRF(r) {
+		return true
+	}
+	h.log.FromContext(r.Context()).Warn("AUTH: CSRF check failed")
+	response.Error(w, r, apperror.ErrInvalidCSRFToken)
+	return false
+}
+
+/// Функция setSessionCookies кладет токены доступа и обновления в HttpOnly cookie, недоступные скриптам страницы, \\\
+/// и выдает новый CSRF токен. CSRF cookie скрипт читает и повторяет в заголовке запросов, меняющих данные. \\\
+/// CSRF cookie живет столько же, сколько сессия - токен обновления: после истечения токена доступа страница \\\
+/// продлевает сессию через /sign_in/refresh, и этот запрос тоже несет CSRF токен \\\
+
+func (h *Handler) setSessionCookies(w http.ResponseWriter, accessToken, refreshToken string) error {
+	csrf, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+	accessAge := int(h.cfg.JWT.AccessExpirationMinutes) * 60
+	sessionAge := int(h.cfg.JWT.RefreshExpirationDays) * 24 * 60 * 60
+	http.SetCookie(w, h.sessionCookie(h.cfg.Auth.AccessCookie, accessToken, "/", accessAge, true))
+	http.SetCookie(w, h.sessionCookie(h.cfg.Auth.RefreshCookie, refreshToken, userRefreshURL, sessionAge, true))
+	http.SetCookie(w, h.sessionCookie(h.cfg.Auth.CSRFCookie, csrf, "/", sessionAge, false))
+	return nil
+}
+
+/// Функция clearSessionCookies удаляет cookie сессии: скрипт страницы сам удалить HttpOnly cookie не может \\\
+
+func (h *Handler) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, h.sessionCookie(h.cfg.Auth.AccessCookie, "", "/", -1, true))
+	http.SetCookie(w, h.sessionCookie(h.cfg.Auth.RefreshCookie, "", userRefreshURL, -1, true))
+	http.SetCookie(w, h.sessionCookie(h.cfg.Auth.CSRFCookie, "", "/", -1, false))
+}
+
+/// Функция sessionCookie собирает cookie сессии с настройками Secure и SameSite из конфигурации. \\\
+/// Браузер отправляет cookie только на адреса внутри path \\\
+
+func (h *Handler) sessionCookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   h.cfg.Auth.CookieSecure,
+		SameSite: sameSite(h.cfg.Auth.SameSite),
+	}
+}
+
+/// Функция sameSite переводит значение auth.same_site из конфигурации в http.SameSite \\\
+
+func sameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case config.SameSiteLax:
+		return http.SameSiteLaxMode
+	case config.SameSiteNone:
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteStrictMode
+}
+
+/// Функция newCSRFToken возвращает случайный CSRF токен из 32 байт \\\
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate CSRF token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
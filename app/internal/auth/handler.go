@@ -4,162 +4,358 @@ import (
 	"Interior_Visualization_Shop/app/internal/apperror"
 	"Interior_Visualization_Shop/app/internal/handler"
 	"Interior_Visualization_Shop/app/internal/mail"
+	"Interior_Visualization_Shop/app/internal/ratelimit"
 	"Interior_Visualization_Shop/app/internal/response"
+	"Interior_Visualization_Shop/app/internal/user"
 	"Interior_Visualization_Shop/app/pkg/config"
 	"Interior_Visualization_Shop/app/pkg/logger"
-	"errors"
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"Interior_Visualization_Shop/app/pkg/token"
+	"embed"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
-	"math/rand"
+	"html/template"
 	"net/http"
-	"strconv"
-	"sync"
+	"net/url"
+	"strings"
 	"time"
 )
 
 const (
-	userAuthByEmailURL   = "/sign_in/mail"
-	userRegisterURL      = "/sign_up"
-	userRegisterCheckURL = "/sign_up/checkmail"
+	userAuthByEmailURL     = "/sign_in/mail"
+	userCSRFURL            = "/sign_in/csrf"
+	userRefreshURL         = "/sign_in/refresh"
+	userSignOutURL         = "/sign_out"
+	userRegisterURL        = "/sign_up"
+	userRegisterCheckURL   = "/sign_up/checkmail"
+	userRegisterConfirmURL = "/sign_up/confirm"
+	jwksURL                = "/.well-known/jwks.json"
 )
 
+/// Страница подтверждения почты, на которую ведет ссылка из письма \\\
+
+//go:embed templates/confirm.html
+var templates embed.FS
+
+var confirmPage = template.Must(template.ParseFS(templates, "templates/confirm.html"))
+
 /// Структура Handler представляющая собой обработчик объекта authService для пользователей \\\
 
 type Handler struct {
 	log         logger.Logger
 	authService Service
 	cfg         config.Config
-	CodeChan    chan string
-	CodeMutex   sync.Mutex
+	mailSender  mail.Sender
+	limiter     *ratelimit.Limiter
+	jwks        token.JWKS
+
+	/// Регистрации, ожидающие подтверждения почты \\\
+	verifications *verifier
 }
 
 /// Структура NewHandler возвращает новый экземпляр Handler инициализируя переданные в него аргументы \\\
+/// Регистрации до подтверждения почты хранятся в verifications \\\
 
-func NewHandler(log logger.Logger, authService Service, cfg config.Config) handler.Hand {
+func NewHandler(log logger.Logger, authService Service, cfg config.Config, mailSender mail.Sender, limiter *ratelimit.Limiter, verifications VerificationStorage, jwks token.JWKS) handler.Hand {
 	return &Handler{
 		log:         log,
 		authService: authService,
 		cfg:         cfg,
+		mailSender:  mailSender,
+		limiter:     limiter,
+		jwks:        jwks,
+		verifications: newVerifier(
+			verifications,
+			time.Duration(cfg.Verification.TTL)*time.Second,
+			cfg.Verification.MaxAttempts,
+		),
 	}
 }
 
 /// Структура Register регистрирует новые запросы для авторизации \\\
 
 func (h *Handler) Register(router *httprouter.Router) {
-	router.HandlerFunc(http.MethodPost, userAuthByEmailURL, h.GetUserByEmail)
-	router.HandlerFunc(http.MethodPost, userRegisterURL, h.RegisterUser)
-	router.HandlerFunc(http.MethodPost, userRegisterCheckURL, h.CheckMailCode)
+	router.HandlerFunc(http.MethodPost, userAuthByEmailURL, h.limiter.Login(userAuthByEmailURL, h.GetUserByEmail))
+	router.HandlerFunc(http.MethodPost, userSignOutURL, h.SignOut)
+	if h.cfg.Auth.Cookies {
+		router.HandlerFunc(http.MethodGet, userCSRFURL, h.CSRF)
+		router.HandlerFunc(http.MethodPost, userRefreshURL, h.Refresh)
+	}
+	router.HandlerFunc(http.MethodPost, userRegisterURL, h.limiter.Attempts(userRegisterURL, h.RegisterUser))
+	router.HandlerFunc(http.MethodPost, userRegisterCheckURL, h.limiter.Attempts(userRegisterCheckURL, h.CheckMailCode))
+	router.HandlerFunc(http.MethodGet, userRegisterConfirmURL, h.ConfirmPage)
+	router.HandlerFunc(http.MethodPost, userRegisterConfirmURL, h.limiter.Attempts(userRegisterConfirmURL, h.ConfirmMail))
+	router.HandlerFunc(http.MethodGet, jwksURL, h.JWKS)
+}
+
+/// Функция JWKS отдает открытые ключи, которыми другие сервисы проверяют токены доступа. \\\
+/// Ответ кэшируется ненадолго, чтобы после смены ключа новый kid быстро стал известен \\\
+
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	response.JSON(w, http.StatusOK, h.jwks)
 }
 
 /// Функция GetUserByEmail получает пользователя по его адресу электронной почты и паролю \\\
 
 func (h *Handler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
-	h.log.Info("HANDLER: AUTH BY EMAIL")
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: AUTH BY EMAIL")
+
+	/// Вход в режиме браузера выдает cookie сессии, поэтому страница входа должна прислать CSRF токен, полученный от CSRF \\\
+	if h.cookieSession(r) && !h.requireCSRF(w, r) {
+		return
+	}
 
 	var input AuthByEmail
 	/// Чтение JSON данных из тела входящего запроса r и декодирование их в переменную input \\\
 	if err := response.ReadJSON(w, r, &input); err != nil {
-		response.BadRequest(w, err.Error(), apperror.ErrInvalidRequestBody.Error())
+		response.Error(w, r, err)
 		return
 	}
-	h.log.Printf("Input: %+v\n", &input)
+	log.Printf("Input: %+v\n", &input)
 	/// Вызов функции AuthByEmail передавая ей полученные значения и ссылку на структуру input \\\
-	user, jwt, err := h.authService.AuthByEmail(r.Context(), &input)
+	u, jwt, err := h.authService.AuthByEmail(r.Context(), &input)
 	if err != nil {
-		if errors.Is(err, apperror.ErrEmptyString) {
-			response.NotFound(w)
+		/// Неизвестный адрес и неверный пароль дают одинаковый ответ ErrInvalidCredentials, чтобы по нему нельзя было перебирать адреса \\\
+		response.Error(w, r, fmt.Errorf("cannot auth user: %w", err))
+		return
+	}
+
+	log.Info("AUTH BY EMAIL IS COMPLETED")
+	h.writeSession(w, r, http.StatusOK, u, jwt)
+}
+
+/// Функция SignOut завершает сессию браузера, удаляя cookie с токенами. API клиенту достаточно забыть свои токены, \\\
+/// поэтому запрос с заголовком Authorization CSRF токен не несет \\\
+
+func (h *Handler) SignOut(w http.ResponseWriter, r *http.Request) {
+	h.log.FromContext(r.Context()).Info("HANDLER: SIGN OUT")
+	if h.sessionFromCookie(r) {
+		if !h.requireCSRF(w, r) {
 			return
 		}
-		response.BadRequest(w, err.Error(), "")
+		h.clearSessionCookies(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/// Функция CSRF выдает странице входа CSRF cookie до входа, чтобы запрос входа мог повторить его в заголовке. \\\
+/// Cookie живет до закрытия браузера, вход заменяет его токеном сессии \\\
+
+func (h *Handler) CSRF(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	if c, err := r.Cookie(h.cfg.Auth.CSRFCookie); err == nil && c.Value != "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	csrf, err := newCSRFToken()
+	if err != nil {
+		response.Error(w, r, err)
 		return
 	}
+	http.SetCookie(w, h.sessionCookie(h.cfg.Auth.CSRFCookie, csrf, "/", 0, false))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/// Функция Refresh продлевает сессию браузера: по cookie с токеном обновления выдает новые cookie сессии. \\\
+/// Страница вызывает его, получив 401 после истечения токена доступа \\\
 
-	h.log.Info("AUTH BY EMAIL IS COMPLETED")
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: REFRESH SESSION")
+
+	if !h.requireCSRF(w, r) {
+		return
+	}
+	c, err := r.Cookie(h.cfg.Auth.RefreshCookie)
+	if err != nil || c.Value == "" {
+		response.Error(w, r, apperror.ErrUnauthorized)
+		return
+	}
+	u, jwt, err := h.authService.Refresh(r.Context(), c.Value)
+	if err != nil {
+		/// Сессию с недействительным токеном не продлить: cookie удаляются, и страница предлагает войти заново \\\
+		if apperror.KindOf(err) == apperror.KindUnauthorized {
+			h.clearSessionCookies(w)
+		}
+		response.Error(w, r, fmt.Errorf("cannot refresh session: %w", err))
+		return
+	}
+	if err = h.setSessionCookies(w, jwt.AccessToken, jwt.RefreshToken); err != nil {
+		response.Error(w, r, err)
+		return
+	}
 	response.JSON(w, http.StatusOK, map[string]interface{}{
-		"user": user,
-		"jwt":  jwt,
+		"user": u,
 	})
 }
 
-/// Функция RegisterUser регистрирует пользователя \\\
+/// Функция writeSession отвечает пользователем и его токенами. В режиме браузера токены уходят в HttpOnly cookie \\\
+/// и в тело ответа не попадают, так что скрипты страницы их не видят \\\
+
+func (h *Handler) writeSession(w http.ResponseWriter, r *http.Request, status int, u *user.User, jwt *AuthResponse) {
+	if !h.cookieSession(r) {
+		response.JSON(w, status, map[string]interface{}{
+			"user": u,
+			"jwt":  jwt,
+		})
+		return
+	}
+	if err := h.setSessionCookies(w, jwt.AccessToken, jwt.RefreshToken); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	response.JSON(w, status, map[string]interface{}{
+		"user": u,
+	})
+}
+
+/// Функция RegisterUser начинает регистрацию: отправляет на почту код подтверждения и, если включено, ссылку. \\\
+/// Пользователь создается после проверки кода в CheckMailCode или перехода по ссылке в ConfirmMail \\\
 
 func (h *Handler) RegisterUser(w http.ResponseWriter, r *http.Request) {
-	h.log.Info("HANDLER: REGISTER USER")
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: REGISTER USER")
 
 	var input Register
 	/// Чтение JSON данных из тела входящего запроса r и декодирование их в переменную input \\\
 	if err := response.ReadJSON(w, r, &input); err != nil {
-		response.BadRequest(w, err.Error(), apperror.ErrInvalidRequestBody.Error())
+		response.Error(w, r, err)
+		return
+	}
+	log.Printf("Input: %+v\n", &input)
+
+	/// До подтверждения почты регистрация лежит в хранилище, поэтому пароль хэшируется сразу \\\
+	if err := input.HashPassword(); err != nil {
+		response.Error(w, r, fmt.Errorf("cannot hash password: %w", err))
 		return
 	}
 
-	/// Формируем 4-х значный код подтверждения \\\
-	h.log.Printf("Input: %+v\n", &input)
-	rand.Seed(time.Now().UnixNano())
-	code := rand.Intn(9000) + 1000
-	codeStr := strconv.Itoa(code)
+	/// Формируем код подтверждения и токен ссылки, в хранилище попадают только их хэши \\\
+	code, err := GenerateCode(h.cfg.Verification.CodeLength, h.cfg.Verification.CodeAlphabet)
+	if err != nil {
+		response.Error(w, r, fmt.Errorf("cannot generate confirmation code: %w", err))
+		return
+	}
+	token, err := newMagicToken()
+	if err != nil {
+		response.Error(w, r, fmt.Errorf("cannot generate confirmation link: %w", err))
+		return
+	}
+	if err = h.verifications.put(r.Context(), input, code, token); err != nil {
+		response.Error(w, r, fmt.Errorf("cannot save confirmation code: %w", err))
+		return
+	}
 
-	/// Считываем данные поты отправителя из env файла \\\
-	from := h.cfg.MAIL.MailAddress
-	password := h.cfg.MAIL.MailPassword
+	var confirmURL string
+	if h.cfg.Verification.MagicLink {
+		confirmURL = strings.TrimRight(h.cfg.Verification.BaseURL, "/") + userRegisterConfirmURL + "?token=" + url.QueryEscape(token)
+	}
 
 	/// Формируем и отправляем письмо пользователю \\\
-	err := mail.SendEmail(from, password, input.Email, input.Name, input.Surname, codeStr)
-	if err != nil {
-		h.log.Errorf("failed to send messeg: %v", err)
+	if err = h.mailSender.SendEmail(r.Context(), input.Email, input.Name, input.Surname, code, confirmURL); err != nil {
+		response.Error(w, r, fmt.Errorf("cannot send confirmation email: %w", err))
+		return
 	}
+	metrics.RegistrationStep(metrics.StepCodeSent)
+
+	log.Info("HANDLER: WAITING FOR THE CODE")
+	response.JSON(w, http.StatusAccepted, map[string]interface{}{
+		"message":    "confirmation code has been sent",
+		"expires_in": h.cfg.Verification.TTL,
+	})
+}
 
-	/// Ждем пока пользователь введет код \\\
-	h.log.Info("HANDLER: WAITING FOR THE CODE")
-	h.CodeChan = make(chan string)
-	go h.CheckMailCode(w, r)
+/// Функция CheckMailCode проверяет введенный пользователем код и завершает регистрацию \\\
 
-	/// Получаем введенный пользователем код из CheckMailCode \\\
-	checkcode := <-h.CodeChan
-	h.log.Info("HANDLER: CODE RECEIVED")
-	h.log.Printf("Input: %+v\n", checkcode)
+func (h *Handler) CheckMailCode(w http.ResponseWriter, r *http.Request) {
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: GETTING THE REGISTRATION CODE")
 
-	/// Сравниваем код введенный пользователем с тем кодом который был отправлен пользователю на почту \\\
-	if checkcode != codeStr {
-		response.BadRequest(w, "the entered code is not correct", apperror.ErrInvalidMailCode.Error())
+	/// Завершение регистрации в режиме браузера тоже выдает cookie сессии \\\
+	if h.cookieSession(r) && !h.requireCSRF(w, r) {
 		return
 	}
 
-	/// Вызов функции Register передавая ей полученные значения и ссылку на структуру input \\\
-	user, jwt, err := h.authService.Register(r.Context(), &input)
+	var input CheckCode
+	/// Чтение JSON данных из тела входящего запроса r и декодирование их в переменную input \\\
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	/// Сравниваем код введенный пользователем с тем кодом который был отправлен пользователю на почту \\\
+	register, err := h.verifications.checkCode(r.Context(), input.Email, input.Code)
 	if err != nil {
-		if errors.Is(err, apperror.ErrRepeatedEmail) {
-			response.BadRequest(w, err.Error(), "")
-			return
-		}
-		response.InternalError(w, fmt.Sprintf("cannot create user: %v", err), "")
+		metrics.RegistrationStep(metrics.StepCodeRejected)
+		response.Error(w, r, err)
 		return
 	}
+	log.Info("HANDLER: CODE RECEIVED")
+	h.completeRegistration(w, r, register)
+}
+
+/// Функция ConfirmPage отдает страницу по ссылке из письма. GET ничего не меняет: почтовые сканеры открывают ссылки сами, \\\
+/// а регистрацию завершает POST со страницы после нажатия кнопки \\\
 
-	h.log.Info("REGISTER USER IS COMPLETED")
-	response.JSON(w, http.StatusCreated, map[string]interface{}{
-		"user": user,
-		"jwt":  jwt,
+func (h *Handler) ConfirmPage(w http.ResponseWriter, r *http.Request) {
+	h.log.FromContext(r.Context()).Info("HANDLER: CONFIRM MAIL PAGE")
+
+	/// Токен в адресе страницы не должен уходить в Referer и оседать в кэшах \\\
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	err := confirmPage.Execute(w, map[string]string{
+		"Token":  r.URL.Query().Get("token"),
+		"Action": userRegisterConfirmURL,
 	})
+	if err != nil {
+		h.log.FromContext(r.Context()).WithError(err).Error("cannot render confirm page")
+	}
 }
 
-/// Функция CheckMailCode получает код подтверждения для RegisterUser \\\
+/// Функция ConfirmMail завершает регистрацию по токену из ссылки, который страница ConfirmPage присылает в теле запроса \\\
 
-func (h *Handler) CheckMailCode(w http.ResponseWriter, r *http.Request) {
-	h.log.Info("HANDLER: GETTING THE REGISTRATION CODE")
+func (h *Handler) ConfirmMail(w http.ResponseWriter, r *http.Request) {
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: CONFIRM MAIL BY LINK")
 
-	var Check struct {
-		Code string `json:"code"`
+	/// Завершение регистрации в режиме браузера тоже выдает cookie сессии \\\
+	if h.cookieSession(r) && !h.requireCSRF(w, r) {
+		return
 	}
 
+	var input ConfirmToken
 	/// Чтение JSON данных из тела входящего запроса r и декодирование их в переменную input \\\
-	if err := response.ReadJSON(w, r, &Check); err != nil {
-		//response.BadRequest(w, err.Error(), apperror.ErrInvalidRequestBody.Error())
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	/// передача введенного пользователем кода через канал CodeChan в RegisterUser \\\
-	h.CodeChan <- Check.Code
+	register, err := h.verifications.checkToken(r.Context(), input.Token)
+	if err != nil {
+		metrics.RegistrationStep(metrics.StepCodeRejected)
+		response.Error(w, r, err)
+		return
+	}
+	h.completeRegistration(w, r, register)
+}
+
+/// Функция completeRegistration создает пользователя после подтверждения почты \\\
+
+func (h *Handler) completeRegistration(w http.ResponseWriter, r *http.Request, input *Register) {
+	log := h.log.FromContext(r.Context())
+	metrics.RegistrationStep(metrics.StepCodeVerified)
+
+	/// Вызов функции Register передавая ей полученные значения и ссылку на структуру input \\\
+	u, jwt, err := h.authService.Register(r.Context(), input)
+	if err != nil {
+		response.Error(w, r, fmt.Errorf("cannot create user: %w", err))
+		return
+	}
 
-	response.JSON(w, http.StatusOK, Check.Code)
+	metrics.RegistrationStep(metrics.StepRegistered)
+	log.Info("REGISTER USER IS COMPLETED")
+	h.writeSession(w, r, http.StatusCreated, u, (*AuthResponse)(jwt))
 }
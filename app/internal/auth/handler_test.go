@@ -0,0 +1,435 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apptest"
+	"Interior_Visualization_Shop/app/internal/ratelimit"
+	"Interior_Visualization_Shop/app/internal/user"
+	"Interior_Visualization_Shop/app/pkg/config"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testConfig() config.Config {
+	var cfg config.Config
+	cfg.JWT.Algorithm = config.JWTAlgorithmHS256
+	cfg.JWT.Issuer = "shop.test"
+	cfg.JWT.Audience = "shop.test"
+	cfg.JWT.AccessExpirationMinutes = 10
+	cfg.JWT.RefreshExpirationDays = 15
+	cfg.JWT.AccessTokenSecretKey = "test-access-secret"
+	cfg.JWT.RefreshTokenSecretKey = "test-refresh-secret"
+	cfg.Verification.CodeLength = 6
+	cfg.Verification.CodeAlphabet = "0123456789"
+	cfg.Verification.TTL = 900
+	cfg.Verification.MaxAttempts = 3
+	cfg.Verification.MagicLink = true
+	cfg.Verification.BaseURL = "http://shop.test/"
+	return cfg
+}
+
+func newTestRouter() (*httprouter.Router, Service, *apptest.Sender) {
+	router, svc, sender, _ := newTestHandler()
+	return router, svc, sender
+}
+
+func newTestHandler() (*httprouter.Router, Service, *apptest.Sender, *Handler) {
+	return newTestHandlerWithConfig(testConfig())
+}
+
+func newTestHandlerWithConfig(cfg config.Config) (*httprouter.Router, Service, *apptest.Sender, *Handler) {
+	log := logger.GetLogger()
+	sender := apptest.NewSender()
+	tokens, err := NewTokens(cfg)
+	if err != nil {
+		panic(err)
+	}
+	svc := NewService(user.NewMemoryStorage(), NewMemorySessionStorage(), log, tokens, nil)
+	router := httprouter.New()
+	limiter := ratelimit.NewLimiter(log, ratelimit.NewMemoryStorage(), ratelimit.Options{Enabled: false})
+	h := NewHandler(log, svc, cfg, sender, limiter, NewMemoryVerificationStorage(), tokens.Access.JWKS()).(*Handler)
+	h.Register(router)
+	return router, svc, sender, h
+}
+
+const registerBody = `{"email":"petrovmaksim1992@mail.ru","name":"Maksim","surname":"Petrov","password":"abcdEFG1"}`
+
+/// Функция startSignUp отправляет форму регистрации и возвращает код и ссылку подтверждения из письма \\\
+
+func startSignUp(t *testing.T, router http.Handler, sender *apptest.Sender) (code, link string) {
+	t.Helper()
+	if rec := apptest.Serve(router, http.MethodPost, "/sign_up", registerBody); rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /sign_up: got status %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+	}
+	return <-sender.Codes, <-sender.URLs
+}
+
+func checkCode(router http.Handler, code string) *httptest.ResponseRecorder {
+	return apptest.Serve(router, http.MethodPost, "/sign_up/checkmail", `{"email":"PetrovMaksim1992@mail.ru","code":"`+code+`"}`)
+}
+
+/// Функция confirmLink открывает ссылку из письма и нажимает кнопку на открывшейся странице \\\
+
+func confirmLink(t *testing.T, router http.Handler, link string) *httptest.ResponseRecorder {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("mailed link: %v", err)
+	}
+	page := apptest.Serve(router, http.MethodGet, u.RequestURI(), "")
+	if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), `value="`+u.Query().Get("token")+`"`) {
+		t.Fatalf("GET %s: got status %d: %s", u.Path, page.Code, page.Body)
+	}
+	return apptest.Serve(router, http.MethodPost, u.Path, `{"token":"`+u.Query().Get("token")+`"}`)
+}
+
+func assertRegistered(t *testing.T, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("confirm registration: got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	var body struct {
+		User user.User        `json:"user"`
+		JWT  RegisterResponse `json:"jwt"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.User.ID < 1 || body.JWT.AccessToken == "" || body.JWT.RefreshToken == "" || strings.Contains(rec.Body.String(), `"password"`) {
+		t.Errorf("confirm registration: got %s", rec.Body)
+	}
+}
+
+func TestSignUp(t *testing.T) {
+	router, _, sender := newTestRouter()
+
+	code, _ := startSignUp(t, router, sender)
+	if len(code) != 6 || strings.Trim(code, "0123456789") != "" {
+		t.Errorf("mailed code: got %q, want 6 digits", code)
+	}
+	assertRegistered(t, checkCode(router, code))
+
+	if rec := checkCode(router, code); rec.Code != http.StatusBadRequest {
+		t.Errorf("reusing the code: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSignUpWrongCode(t *testing.T) {
+	router, _, sender := newTestRouter()
+
+	code, _ := startSignUp(t, router, sender)
+	if rec := checkCode(router, "0"+code); rec.Code != http.StatusBadRequest {
+		t.Errorf("wrong code: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	assertRegistered(t, checkCode(router, code))
+}
+
+func TestSignUpTooManyAttempts(t *testing.T) {
+	router, _, sender := newTestRouter()
+
+	code, _ := startSignUp(t, router, sender)
+	for i := 0; i < 3; i++ {
+		checkCode(router, "x"+code)
+	}
+	rec := checkCode(router, code)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("right code after too many attempts: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSignUpExpiredCode(t *testing.T) {
+	router, _, sender, h := newTestHandler()
+
+	code, link := startSignUp(t, router, sender)
+	h.verifications.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if rec := checkCode(router, code); rec.Code != http.StatusBadRequest {
+		t.Errorf("expired code: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := confirmLink(t, router, link); rec.Code != http.StatusBadRequest {
+		t.Errorf("expired link: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSignUpMagicLink(t *testing.T) {
+	router, _, sender := newTestRouter()
+
+	code, link := startSignUp(t, router, sender)
+	if !strings.HasPrefix(link, "http://shop.test/sign_up/confirm?token=") {
+		t.Fatalf("mailed link: got %q", link)
+	}
+
+	/// Переход по ссылке только показывает страницу, поэтому почтовые сканеры, открывающие ссылки, никого не регистрируют \\\
+	for i := 0; i < 2; i++ {
+		if rec := apptest.Serve(router, http.MethodGet, strings.TrimPrefix(link, "http://shop.test"), ""); rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != "no-store" {
+			t.Fatalf("GET the link: got status %d, Cache-Control %q", rec.Code, rec.Header().Get("Cache-Control"))
+		}
+	}
+	assertRegistered(t, confirmLink(t, router, link))
+
+	if rec := confirmLink(t, router, link); rec.Code != http.StatusBadRequest {
+		t.Errorf("reusing the link: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := checkCode(router, code); rec.Code != http.StatusBadRequest {
+		t.Errorf("code after the link: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSignIn(t *testing.T) {
+	router, svc, _ := newTestRouter()
+	if _, _, err := svc.Register(context.Background(), hashed(t, Register{
+		Email: "petrovmaksim1992@mail.ru", Name: "Maksim", Surname: "Petrov", Password: "abcdEFG1",
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	rec := apptest.Serve(router, http.MethodPost, "/sign_in/mail", `{"email":"petrovmaksim1992@mail.ru","password":"abcdEFG1"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /sign_in/mail: got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var body struct {
+		JWT AuthResponse `json:"jwt"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.JWT.AccessToken == "" || strings.Contains(rec.Body.String(), `"password"`) {
+		t.Errorf("POST /sign_in/mail: got %s", rec.Body)
+	}
+
+	claims, err := newTestTokens(t, testConfig()).Access.Verify(body.JWT.AccessToken)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.User.Email != "petrovmaksim1992@mail.ru" {
+		t.Errorf("Verify: got %+v", claims.User)
+	}
+
+	if rec = apptest.Serve(router, http.MethodPost, "/sign_in/mail", `{"email":`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST /sign_in/mail with malformed JSON: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func cookieConfig() config.Config {
+	cfg := testConfig()
+	cfg.Auth.Cookies = true
+	cfg.Auth.AccessCookie = "access_token"
+	cfg.Auth.RefreshCookie = "refresh_token"
+	cfg.Auth.CSRFCookie = "csrf_token"
+	cfg.Auth.CSRFHeader = "X-CSRF-Token"
+	cfg.Auth.CookieSecure = true
+	cfg.Auth.SameSite = config.SameSiteStrict
+	return cfg
+}
+
+/// Функция serveBrowser отправляет POST так же, как страницы сайта: с cookie и, если задан, с заголовком CSRF \\\
+
+func serveBrowser(router http.Handler, target, body, csrf string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	return apptest.Serve(router, http.MethodPost, target, body, "X-CSRF-Token", csrf, "Cookie", apptest.Cookies(cookies...))
+}
+
+func cookiesOf(rec *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, c := range rec.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	return cookies
+}
+
+/// Функция browserSignIn получает CSRF cookie как страница входа и входит с ним \\\
+
+func browserSignIn(t *testing.T, router http.Handler) map[string]*http.Cookie {
+	t.Helper()
+	rec := apptest.Serve(router, http.MethodGet, "/sign_in/csrf", "")
+	csrf := cookiesOf(rec)["csrf_token"]
+	if rec.Code != http.StatusNoContent || csrf == nil || csrf.Value == "" || csrf.HttpOnly || csrf.MaxAge != 0 {
+		t.Fatalf("GET /sign_in/csrf: got status %d and cookies %v", rec.Code, rec.Result().Cookies())
+	}
+	rec = serveBrowser(router, "/sign_in/mail?session=cookie", `{"email":"petrovmaksim1992@mail.ru","password":"abcdEFG1"}`, csrf.Value, csrf)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `"password"`) {
+		t.Fatalf("POST /sign_in/mail?session=cookie: got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	cookies := cookiesOf(rec)
+	if cookies["csrf_token"] == nil || cookies["csrf_token"].Value == csrf.Value {
+		t.Errorf("sign in kept the CSRF token issued before it")
+	}
+	return cookies
+}
+
+func TestSignInCookieSession(t *testing.T) {
+	cfg := cookieConfig()
+	router, svc, _, _ := newTestHandlerWithConfig(cfg)
+	if _, _, err := svc.Register(context.Background(), hashed(t, Register{
+		Email: "petrovmaksim1992@mail.ru", Name: "Maksim", Surname: "Petrov", Password: "abcdEFG1",
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	const credentials = `{"email":"petrovmaksim1992@mail.ru","password":"abcdEFG1"}`
+
+	if rec := apptest.Serve(router, http.MethodPost, "/sign_in/mail?session=cookie", credentials); rec.Code != http.StatusForbidden || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("cookie sign in without a CSRF token: got status %d and cookies %v", rec.Code, rec.Result().Cookies())
+	}
+
+	cookies := browserSignIn(t, router)
+	for name, want := range map[string]struct {
+		httpOnly bool
+		path     string
+	}{
+		"access_token":  {true, "/"},
+		"refresh_token": {true, "/sign_in/refresh"},
+		"csrf_token":    {false, "/"},
+	} {
+		c := cookies[name]
+		if c == nil || c.Value == "" {
+			t.Errorf("cookie %s is not set", name)
+			continue
+		}
+		if c.HttpOnly != want.httpOnly || !c.Secure || c.SameSite != http.SameSiteStrictMode || c.Path != want.path || c.MaxAge <= 0 {
+			t.Errorf("cookie %s: %+v", name, c)
+		}
+	}
+	if c := cookies["access_token"]; c != nil {
+		if _, err := newTestTokens(t, cfg).Access.Verify(c.Value); err != nil {
+			t.Errorf("access cookie: %v", err)
+		}
+		if c.MaxAge != 10*60 {
+			t.Errorf("access cookie lives %d seconds, want the token lifetime", c.MaxAge)
+		}
+	}
+	if csrf, refresh := cookies["csrf_token"], cookies["refresh_token"]; csrf != nil && refresh != nil && csrf.MaxAge != refresh.MaxAge {
+		t.Errorf("CSRF cookie lives %d seconds, the session %d", csrf.MaxAge, refresh.MaxAge)
+	}
+
+	rec := apptest.Serve(router, http.MethodPost, "/sign_in/mail", credentials)
+	if !strings.Contains(rec.Body.String(), "access_token") || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("API client sign in: got cookies %v and body %s", rec.Result().Cookies(), rec.Body)
+	}
+
+	/// API клиент с токеном в заголовке Authorization выходит без CSRF токена, его cookie не трогаются \\\
+	var signedIn struct {
+		JWT AuthResponse `json:"jwt"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &signedIn); err != nil {
+		t.Fatal(err)
+	}
+	api := apptest.Serve(router, http.MethodPost, "/sign_out", "", "Authorization", "Bearer "+signedIn.JWT.AccessToken)
+	if api.Code != http.StatusNoContent || len(api.Result().Cookies()) != 0 {
+		t.Errorf("API client sign out: got status %d and cookies %v", api.Code, api.Result().Cookies())
+	}
+
+	session := []*http.Cookie{cookies["access_token"], cookies["csrf_token"]}
+	if rec = serveBrowser(router, "/sign_out", "{}", "", session...); rec.Code != http.StatusForbidden || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("POST /sign_out without a CSRF token: got status %d and cookies %v", rec.Code, rec.Result().Cookies())
+	}
+	rec = serveBrowser(router, "/sign_out", "{}", cookies["csrf_token"].Value, session...)
+	if rec.Code != http.StatusNoContent || len(rec.Result().Cookies()) != 3 {
+		t.Fatalf("POST /sign_out: got status %d and cookies %v", rec.Code, rec.Result().Cookies())
+	}
+	for _, c := range rec.Result().Cookies() {
+		if c.Value != "" || c.MaxAge >= 0 || c.Path != cookies[c.Name].Path {
+			t.Errorf("POST /sign_out: cookie %s is not removed: %+v", c.Name, c)
+		}
+	}
+}
+
+func TestRefreshSession(t *testing.T) {
+	cfg := cookieConfig()
+	router, svc, _, _ := newTestHandlerWithConfig(cfg)
+	if _, _, err := svc.Register(context.Background(), hashed(t, Register{
+		Email: "petrovmaksim1992@mail.ru", Name: "Maksim", Surname: "Petrov", Password: "abcdEFG1",
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	cookies := browserSignIn(t, router)
+	csrf, refresh := cookies["csrf_token"], cookies["refresh_token"]
+
+	if rec := serveBrowser(router, "/sign_in/refresh", "", "", csrf, refresh); rec.Code != http.StatusForbidden {
+		t.Errorf("refresh without a CSRF token: got status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := serveBrowser(router, "/sign_in/refresh", "", csrf.Value, csrf); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh without the refresh cookie: got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	rec := serveBrowser(router, "/sign_in/refresh", "", csrf.Value, csrf, refresh)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"user"`) || strings.Contains(rec.Body.String(), "jwt") {
+		t.Fatalf("POST /sign_in/refresh: got status %d: %s", rec.Code, rec.Body)
+	}
+	renewed := cookiesOf(rec)
+	for _, name := range []string{"access_token", "refresh_token", "csrf_token"} {
+		if renewed[name] == nil || renewed[name].Value == "" || renewed[name].Path != cookies[name].Path {
+			t.Errorf("POST /sign_in/refresh: cookie %s is not renewed: %+v", name, renewed[name])
+		}
+	}
+	verify := newTestTokens(t, cfg)
+	before, err := verify.Access.Verify(cookies["access_token"].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after, err := verify.Access.Verify(renewed["access_token"].Value); err != nil || after.SessionID != before.SessionID || after.User.Email != "petrovmaksim1992@mail.ru" {
+		t.Errorf("renewed access token: %+v, %v", after, err)
+	}
+
+	/// Токен обновления одноразовый: повторно предъявленный старый токен завершает сессию, и новый тоже перестает действовать \\\
+	if renewed["refresh_token"].Value == refresh.Value {
+		t.Fatal("POST /sign_in/refresh: the refresh token is not rotated")
+	}
+	rec = serveBrowser(router, "/sign_in/refresh", "", csrf.Value, csrf, refresh)
+	if rec.Code != http.StatusUnauthorized || len(rec.Result().Cookies()) != 3 {
+		t.Errorf("refresh with a used token: got status %d and cookies %v", rec.Code, rec.Result().Cookies())
+	}
+	rec = serveBrowser(router, "/sign_in/refresh", "", renewed["csrf_token"].Value, renewed["csrf_token"], renewed["refresh_token"])
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after the used token was replayed: got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	forged := &http.Cookie{Name: "refresh_token", Value: cookies["access_token"].Value}
+	rec = serveBrowser(router, "/sign_in/refresh", "", csrf.Value, csrf, forged)
+	if rec.Code != http.StatusUnauthorized || len(rec.Result().Cookies()) != 3 {
+		t.Errorf("refresh with an access token: got status %d and cookies %v", rec.Code, rec.Result().Cookies())
+	}
+}
+
+func TestSignInCookieSessionDisabled(t *testing.T) {
+	router, svc, _ := newTestRouter()
+	if _, _, err := svc.Register(context.Background(), hashed(t, Register{
+		Email: "petrovmaksim1992@mail.ru", Name: "Maksim", Surname: "Petrov", Password: "abcdEFG1",
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	rec := apptest.Serve(router, http.MethodPost, "/sign_in/mail?session=cookie", `{"email":"petrovmaksim1992@mail.ru","password":"abcdEFG1"}`)
+	if !strings.Contains(rec.Body.String(), "access_token") || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("cookie session while disabled: got cookies %v and body %s", rec.Result().Cookies(), rec.Body)
+	}
+	if rec = apptest.Serve(router, http.MethodPost, "/sign_in/refresh", "{}"); rec.Code != http.StatusNotFound {
+		t.Errorf("POST /sign_in/refresh while disabled: got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestSignInInvalidCredentials(t *testing.T) {
+	router, svc, _ := newTestRouter()
+	if _, _, err := svc.Register(context.Background(), hashed(t, Register{
+		Email: "petrovmaksim1992@mail.ru", Name: "Maksim", Surname: "Petrov", Password: "abcdEFG1",
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	wrongPassword := apptest.Serve(router, http.MethodPost, "/sign_in/mail", `{"email":"petrovmaksim1992@mail.ru","password":"wrong"}`)
+	unknownEmail := apptest.Serve(router, http.MethodPost, "/sign_in/mail", `{"email":"missing@mail.ru","password":"abcdEFG1"}`)
+	for name, rec := range map[string]*httptest.ResponseRecorder{"wrong password": wrongPassword, "unknown email": unknownEmail} {
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: got status %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+		if strings.Contains(rec.Body.String(), "jwt") {
+			t.Errorf("%s: response contains tokens: %s", name, rec.Body)
+		}
+	}
+	if wrongPassword.Body.String() != unknownEmail.Body.String() {
+		t.Errorf("responses differ: %s vs %s", wrongPassword.Body, unknownEmail.Body)
+	}
+}
@@ -2,13 +2,19 @@ package auth
 
 import (
 	"Interior_Visualization_Shop/app/internal/apperror"
+	authmw "Interior_Visualization_Shop/app/internal/middleware/auth"
+	"Interior_Visualization_Shop/app/internal/normalize"
 	"Interior_Visualization_Shop/app/internal/user"
-	"Interior_Visualization_Shop/app/pkg/config"
 	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/token"
+	"Interior_Visualization_Shop/app/pkg/tracing"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/crypto/bcrypt"
+	"sync"
 	"time"
 )
 
@@ -17,61 +23,79 @@ import (
 type Service interface {
 	AuthByEmail(ctx context.Context, user *AuthByEmail) (*user.User, *AuthResponse, error)
 	Register(ctx context.Context, user *Register) (*user.User, *RegisterResponse, error)
-	CreateAccessToken(cfg *config.Config, user *user.User) (string, error)
-	CreateRefreshToken(cfg *config.Config, user *user.User) (string, error)
-	ParseToken(token string) (string, error)
+	Refresh(ctx context.Context, refreshToken string) (*user.User, *AuthResponse, error)
 }
 
 /// Структура  service реализизирующая инфтерфейс Service пользователей \\\
 
 type service struct {
-	log     logger.Logger
-	storage user.Storage
-	cfg     config.Config
+	log        logger.Logger
+	storage    user.Storage
+	sessions   SessionStorage
+	access     token.Issuer
+	refresh    token.Issuer
+	verify     token.Verifier
+	refreshTTL time.Duration
+	admins     map[string]bool
+	now        func() time.Time
 }
 
 /// Структура NewService возвращает новый экземпляр Service инициализируя переданные в него аргументы \\\
-
-func NewService(storage user.Storage, log logger.Logger, cfg config.Config) Service {
-	return &service{
-		log:     log,
-		storage: storage,
-		cfg:     cfg,
-	}
+/// Действующие токены обновления сессий хранятся в sessions. Пользователи с адресами из admins получают в токене роль admin \\\
+
+func NewService(storage user.Storage, sessions SessionStorage, log logger.Logger, tokens *Tokens, admins []string) Service {
+	s := &service{
+		log:        log,
+		storage:    storage,
+		sessions:   sessions,
+		access:     tokens.Access,
+		refresh:    tokens.Refresh,
+		verify:     tokens.Refresh,
+		refreshTTL: tokens.Refresh.TTL(),
+		admins:     make(map[string]bool, len(admins)),
+		now:        time.Now,
+	}
+	for _, email := range admins {
+		s.admins[normalize.Email(email)] = true
+	}
+	return s
 }
 
-// / Структура tokenClaims хранящая информацию о сессиях пользователей \\\
-type tokenClaims struct {
-	jwt.MapClaims
-	Email string `json:"email"`
-}
+/// Функция dummyHash возвращает bcrypt хэш, с которым сверяется пароль для неизвестного адреса \\\
+
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
 
 /// Функция AuthByEmail реализует аутентификацию пользователя по адресу электронной почты через интерфейс Service принимая входные данные input  \\\
 
 func (s *service) AuthByEmail(ctx context.Context, input *AuthByEmail) (*user.User, *AuthResponse, error) {
-	s.log.Info("SERVICE: AUTH USER BY EMAIL")
+	ctx, span := tracing.Start(ctx, "auth.Service.AuthByEmail")
+	defer span.End()
+	log := s.log.FromContext(ctx)
+	log.Info("SERVICE: AUTH USER BY EMAIL")
 
 	/// Вызов функции FindByEmail в хранилище пользователей  \\\
-	user, err := s.storage.FindByEmail(input.Email)
+	user, err := s.storage.FindByEmail(ctx, input.Email)
 	if err != nil {
-		if errors.Is(err, apperror.ErrEmptyString) {
+		if !errors.Is(err, apperror.ErrNotFound) {
+			log.Errorf("cannot find user by email: %v", err)
 			return nil, nil, err
 		}
-		s.log.Error("cannot find user by email:", err)
-		return nil, nil, err
+		/// Для неизвестного адреса пароль все равно сверяется с хэшем, чтобы время ответа не выдавало, зарегистрирован ли email \\\
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(input.Password))
+		log.Warn("SERVICE: UNKNOWN EMAIL")
+		return nil, nil, apperror.ErrInvalidCredentials
 	}
 	/// Проверка на соответствие введенного и захэшированного пароля в хранилище \\\
 	if !user.CheckPassword(input.Password) {
-		s.log.Error("incorrect password:", err)
-		return nil, nil, err
+		log.Warn("SERVICE: INCORRECT PASSWORD")
+		return nil, nil, apperror.ErrInvalidCredentials
 	}
 
 	/// Создание токенов доступа \\\
-	accessToken, err := s.CreateAccessToken(&s.cfg, user)
-	if err != nil {
-		return nil, nil, err
-	}
-	refreshToken, err := s.CreateRefreshToken(&s.cfg, user)
+	accessToken, refreshToken, err := s.issueTokens(ctx, user)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -81,14 +105,18 @@ func (s *service) AuthByEmail(ctx context.Context, input *AuthByEmail) (*user.Us
 	}, nil
 }
 
-/// Функция Register реализует регистрацию пользователя через интерфейс Service принимая входные данные input  \\\
+/// Функция Register реализует регистрацию пользователя через интерфейс Service принимая входные данные input. \\\
+/// Пароль в input уже захэширован Register.HashPassword при начале регистрации \\\
 
 func (s *service) Register(ctx context.Context, input *Register) (*user.User, *RegisterResponse, error) {
-	s.log.Info("SERVICE: REGISTER USER")
+	ctx, span := tracing.Start(ctx, "auth.Service.Register")
+	defer span.End()
+	log := s.log.FromContext(ctx)
+	log.Info("SERVICE: REGISTER USER")
 
 	/// Проверка на повтаряющийся адрес электронной почты \\\
 	/// Вызов функции FindByEmail в хранилище пользователей  \\\
-	checkEmail, err := s.storage.FindByEmail(input.Email)
+	checkEmail, err := s.storage.FindByEmail(ctx, input.Email)
 	if err != nil {
 		if !errors.Is(err, apperror.ErrNotFound) {
 			return nil, nil, err
@@ -106,21 +134,14 @@ func (s *service) Register(ctx context.Context, input *Register) (*user.User, *R
 		Password: input.Password,
 	}
 
-	/// Хэширование полученного пароля \\\
-	err = u.HashPassword()
-	if err != nil {
-		return nil, nil, fmt.Errorf("cannot hash password")
-	}
-
 	/// Вызов функции Create в хранилище пользователей  \\\
-	user, err := s.storage.Create(&u)
-
-	/// Создание токенов доступа \\\
-	accessToken, err := s.CreateAccessToken(&s.cfg, user)
+	user, err := s.storage.Create(ctx, &u)
 	if err != nil {
 		return nil, nil, err
 	}
-	refreshToken, err := s.CreateRefreshToken(&s.cfg, user)
+
+	/// Создание токенов доступа \\\
+	accessToken, refreshToken, err := s.issueTokens(ctx, user)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -130,72 +151,101 @@ func (s *service) Register(ctx context.Context, input *Register) (*user.User, *R
 	}, nil
 }
 
-/// Функция CreateAccessToken для создания токена доступа AccessToken \\\
-
-func (s *service) CreateAccessToken(cfg *config.Config, user *user.User) (string, error) {
-	s.log.Info("SERVICE: CREATE ACCESS TOKEN")
-	metadata := AccessToken{
-		ID:      user.ID,
-		Email:   user.Email,
-		Name:    user.Name,
-		Surname: user.Surname,
-	}
-
-	/// Создание нового токена accessToken с указанными утверждениями claims и методом подписи SigningMethodHS256 \\\
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
-		jwt.MapClaims{
-			"user": metadata,
-			"exp":  time.Duration(cfg.JWT.AccessExpirationMinutes) * time.Minute,
-		}, metadata.Email,
-	})
-	/// Токен подписывается с помощью секретного ключа AccessTokenSecretKey и преобразуется в строку с помощью метода SignedString \\\
-	token, err := accessToken.SignedString([]byte(cfg.JWT.AccessTokenSecretKey))
+/// Функция Refresh выпускает новую пару токенов по токену обновления refreshToken в той же сессии входа. \\\
+/// Пользователь читается из хранилища заново, чтобы в токен доступа попали его текущие данные и роли. \\\
+/// Старый токен обновления после этого недействителен, а его повторное предъявление означает кражу и завершает сессию \\\
+
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*user.User, *AuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.Refresh")
+	defer span.End()
+	log := s.log.FromContext(ctx)
+	log.Info("SERVICE: REFRESH TOKENS")
+
+	claims, err := s.verify.Verify(refreshToken)
 	if err != nil {
-		return "", err
+		log.Warnf("SERVICE: REJECTED REFRESH TOKEN: %v", err)
+		return nil, nil, apperror.Wrap(err, apperror.KindUnauthorized, apperror.ErrUnauthorized.Message)
 	}
-	return token, nil
-}
-
-/// Функция CreateRefreshToken для создания токена обновления RefreshToken \\\
 
-func (s *service) CreateRefreshToken(cfg *config.Config, user *user.User) (string, error) {
-	s.log.Info("SERVICE: CREATE REFRESH TOKEN")
+	/// Вызов функции FindById в хранилище пользователей: удаленный пользователь сессию продлить не может \\\
+	user, err := s.storage.FindById(ctx, claims.User.ID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			log.Warn("SERVICE: REFRESH FOR A DELETED USER")
+			return nil, nil, apperror.ErrUnauthorized
+		}
+		return nil, nil, err
+	}
 
-	/// Добавление id пользователя в мапу claims, и время истечения действия токена RefreshToken \\\
-	claims := jwt.MapClaims{
-		"id":  user.ID,
-		"exp": time.Duration(cfg.JWT.RefreshExpirationDays) * time.Hour * 24,
+	accessToken, newRefreshToken, err := s.sessionTokens(user, claims.SessionID)
+	if err != nil {
+		return nil, nil, err
 	}
-	/// Создание нового токена refreshToken с указанными утверждениями claims и методом подписи SigningMethodHS256 \\\
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	/// Токен подписывается с помощью секретного ключа RefreshTokenSecretKey и преобразуется в строку с помощью метода SignedString \\\
-	token, err := refreshToken.SignedString([]byte(cfg.JWT.RefreshTokenSecretKey))
+	/// Вызов функции Rotate в хранилище сессий: токен обновления одноразовый \\\
+	err = s.sessions.Rotate(ctx, claims.SessionID, hashRefreshToken(refreshToken), hashRefreshToken(newRefreshToken), s.now().Add(s.refreshTTL))
 	if err != nil {
-		return "", err
+		if errors.Is(err, apperror.ErrNotFound) {
+			log.Warn("SERVICE: REFRESH TOKEN REUSED OR SESSION REVOKED, SESSION CLOSED")
+			return nil, nil, apperror.ErrUnauthorized
+		}
+		return nil, nil, err
 	}
-
-	return token, nil
+	return user, &AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+	}, nil
 }
 
-/// Функция ParseToken для передачи accessToken токена \\\
-
-func (s *service) ParseToken(accessToken string) (string, error) {
-	s.log.Info("HANDLER: PARSE TOKEN")
+/// Функция issueTokens выпускает токены доступа и обновления для новой сессии входа и сохраняет сессию \\\
 
-	token, err := jwt.ParseWithClaims(accessToken, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("invalid metod")
-		}
-		return []byte(s.cfg.JWT.AccessTokenSecretKey), nil
-	})
+func (s *service) issueTokens(ctx context.Context, u *user.User) (accessToken, refreshToken string, err error) {
+	sessionID, err := newSessionID()
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, refreshToken, err = s.sessionTokens(u, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+	/// Вызов функции Save в хранилище сессий \\\
+	if err = s.sessions.Save(ctx, sessionID, hashRefreshToken(refreshToken), s.now().Add(s.refreshTTL)); err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
 
+/// Функция sessionTokens выпускает токены доступа и обновления с общим идентификатором сессии sessionID. \\\
+/// В токене обновления только идентификатор пользователя \\\
+
+func (s *service) sessionTokens(u *user.User, sessionID string) (accessToken, refreshToken string, err error) {
+	s.log.Info("SERVICE: ISSUE TOKENS")
+	roles := []string{authmw.RoleUser}
+	if s.admins[u.Email] {
+		roles = append(roles, authmw.RoleAdmin)
+	}
+	accessToken, err = s.access.Issue(token.User{
+		ID:      u.ID,
+		Email:   u.Email,
+		Name:    u.Name,
+		Surname: u.Surname,
+		Roles:   roles,
+	}, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err = s.refresh.Issue(token.User{ID: u.ID}, sessionID)
 	if err != nil {
-		return "", fmt.Errorf("bad recived token")
+		return "", "", err
 	}
+	return accessToken, refreshToken, nil
+}
+
+/// Функция newSessionID генерирует случайный идентификатор сессии входа \\\
 
-	claim, ok := token.Claims.(*tokenClaims)
-	if !ok {
-		return "", fmt.Errorf("token claim bad type")
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate session id: %w", err)
 	}
-	return claim.Email, nil
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/internal/user"
+	"Interior_Visualization_Shop/app/pkg/config"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/token"
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+/// Структура failingStorage отвечает ошибкой на каждый поиск, как недоступная база \\\
+
+type failingStorage struct {
+	user.Storage
+}
+
+func (failingStorage) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestAuthByEmail(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, user.NewMemoryStorage(), testConfig())
+	if _, _, err := svc.Register(ctx, hashed(t, Register{
+		Email: "petrovmaksim1992@mail.ru", Name: "Maksim", Surname: "Petrov", Password: "abcdEFG",
+	})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	u, jwt, err := svc.AuthByEmail(ctx, &AuthByEmail{Email: "petrovmaksim1992@mail.ru", Password: "abcdEFG"})
+	if err != nil {
+		t.Fatalf("AuthByEmail: %v", err)
+	}
+	if u == nil || jwt == nil || jwt.AccessToken == "" {
+		t.Errorf("AuthByEmail: got user %v, tokens %v", u, jwt)
+	}
+
+	for name, input := range map[string]AuthByEmail{
+		"wrong password": {Email: "petrovmaksim1992@mail.ru", Password: "wrong"},
+		"unknown email":  {Email: "missing@mail.ru", Password: "abcdEFG"},
+		"empty input":    {},
+	} {
+		u, jwt, err := svc.AuthByEmail(ctx, &input)
+		if !errors.Is(err, apperror.ErrInvalidCredentials) {
+			t.Errorf("%s: got error %v, want %v", name, err, apperror.ErrInvalidCredentials)
+		}
+		if u != nil || jwt != nil {
+			t.Errorf("%s: got user %v, tokens %v, want nil", name, u, jwt)
+		}
+	}
+
+	failing := newTestService(t, failingStorage{}, testConfig())
+	if _, _, err = failing.AuthByEmail(ctx, &AuthByEmail{Email: "petrovmaksim1992@mail.ru", Password: "abcdEFG"}); err == nil || errors.Is(err, apperror.ErrInvalidCredentials) {
+		t.Errorf("storage failure: got error %v, want the storage error", err)
+	}
+}
+
+/// Функция hashed возвращает регистрацию с захэшированным паролем, как ее хранит обработчик до подтверждения почты \\\
+
+func hashed(t *testing.T, input Register) *Register {
+	t.Helper()
+	if err := input.HashPassword(); err != nil {
+		t.Fatal(err)
+	}
+	return &input
+}
+
+func newTestTokens(t *testing.T, cfg config.Config) *Tokens {
+	t.Helper()
+	tokens, err := NewTokens(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+func newTestService(t *testing.T, storage user.Storage, cfg config.Config, admins ...string) Service {
+	t.Helper()
+	return NewService(storage, NewMemorySessionStorage(), logger.GetLogger(), newTestTokens(t, cfg), admins)
+}
+
+func TestIssuedTokens(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	svc := newTestService(t, user.NewMemoryStorage(), cfg)
+	u, tokens, err := svc.Register(ctx, hashed(t, Register{
+		Email: "petrovmaksim1992@mail.ru", Name: "Maksim", Surname: "Petrov", Password: "abcdEFG",
+	}))
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	verify := newTestTokens(t, cfg)
+	claims, err := verify.Access.Verify(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+	want := token.User{ID: u.ID, Email: u.Email, Name: "Maksim", Surname: "Petrov", Roles: []string{"user"}}
+	if !reflect.DeepEqual(claims.User, want) || claims.Subject != strconv.FormatInt(u.ID, 10) || claims.SessionID == "" {
+		t.Errorf("access token claims: %+v", claims)
+	}
+	refresh, err := verify.Refresh.Verify(tokens.RefreshToken)
+	if err != nil || !reflect.DeepEqual(refresh.User, token.User{ID: u.ID}) {
+		t.Fatalf("refresh token: %+v, %v", refresh, err)
+	}
+	if refresh.SessionID != claims.SessionID {
+		t.Errorf("access and refresh tokens of one sign-in have different sessions: %q and %q", claims.SessionID, refresh.SessionID)
+	}
+	if _, err := verify.Access.Verify(tokens.RefreshToken); err == nil {
+		t.Error("refresh token accepted as an access token")
+	}
+}
+
+func TestAdminRole(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	svc := newTestService(t, user.NewMemoryStorage(), cfg, "PetrovMaksim1992@Mail.ru")
+	verify := newTestTokens(t, cfg)
+
+	for _, email := range []string{"petrovmaksim1992@mail.ru", "ivanov@mail.ru"} {
+		if _, _, err := svc.Register(ctx, hashed(t, Register{Email: email, Name: "Maksim", Surname: "Petrov", Password: "abcdEFG"})); err != nil {
+			t.Fatalf("Register %s: %v", email, err)
+		}
+	}
+	for email, want := range map[string][]string{
+		"petrovmaksim1992@mail.ru": {"user", "admin"},
+		"ivanov@mail.ru":           {"user"},
+	} {
+		_, tokens, err := svc.AuthByEmail(ctx, &AuthByEmail{Email: email, Password: "abcdEFG"})
+		if err != nil {
+			t.Fatalf("AuthByEmail %s: %v", email, err)
+		}
+		claims, err := verify.Access.Verify(tokens.AccessToken)
+		if err != nil || !reflect.DeepEqual(claims.User.Roles, want) {
+			t.Errorf("%s: got roles %v, %v, want %v", email, claims.User.Roles, err, want)
+		}
+	}
+}
+
+func TestTokensAfterKeyRotation(t *testing.T) {
+	u := token.User{ID: 7, Email: "petrovmaksim1992@mail.ru"}
+	before := testConfig()
+	before.JWT.AccessTokenKeyID = "2024-01"
+	old, err := newTestTokens(t, before).Access.Issue(u, "test-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := testConfig()
+	after.JWT.AccessTokenKeyID = "2024-06"
+	after.JWT.AccessTokenSecretKey = "rotated-access-secret"
+	after.JWT.PreviousAccessTokenKeys = "2024-01:" + before.JWT.AccessTokenSecretKey
+	tokens := newTestTokens(t, after)
+	fresh, err := tokens.Access.Issue(u, "test-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, raw := range map[string]string{"old key": old, "new key": fresh} {
+		if claims, err := tokens.Access.Verify(raw); err != nil || !reflect.DeepEqual(claims.User, u) {
+			t.Errorf("%s: got %+v, %v", name, claims, err)
+		}
+	}
+
+	after.JWT.PreviousAccessTokenKeys = ""
+	if _, err := newTestTokens(t, after).Access.Verify(old); err == nil {
+		t.Error("token signed with a retired key accepted")
+	}
+}
+
+func TestTokensWithSharedSecret(t *testing.T) {
+	/// В конфигурации по умолчанию издатель и аудитория совпадают, и токены разных видов различает только аудитория токенов обновления \\\
+	cfg := testConfig()
+	cfg.JWT.RefreshTokenSecretKey = cfg.JWT.AccessTokenSecretKey
+	tokens := newTestTokens(t, cfg)
+	u := token.User{ID: 7}
+
+	access, err := tokens.Access.Issue(u, "test-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh, err := tokens.Refresh.Issue(u, "test-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokens.Refresh.Verify(access); err == nil {
+		t.Error("access token accepted as a refresh token")
+	}
+	if _, err := tokens.Access.Verify(refresh); err == nil {
+		t.Error("refresh token accepted as an access token")
+	}
+	claims, err := tokens.Refresh.Verify(refresh)
+	if err != nil {
+		t.Fatalf("refresh token: %v", err)
+	}
+	if want := []string{"shop.test/refresh"}; !reflect.DeepEqual([]string(claims.Audience), want) {
+		t.Errorf("refresh audience = %v, want %v", claims.Audience, want)
+	}
+}
+
+func TestNewTokensRejectsBadKeys(t *testing.T) {
+	cfg := testConfig()
+	cfg.JWT.Algorithm = config.JWTAlgorithmRS256
+	cfg.JWT.AccessTokenPrivateKey = "not a PEM key"
+	if _, err := NewTokens(cfg); err == nil {
+		t.Error("RS256 without a valid private key accepted")
+	}
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"time"
+)
+
+/// Интерфейс SessionStorage - хранилище сессий входа. Для каждой сессии хранится хэш единственного действующего \\\
+/// токена обновления: продление сессии заменяет его новым, а повторно предъявленный старый токен завершает сессию \\\
+
+type SessionStorage interface {
+	/// Save сохраняет новую сессию sessionID с хэшем токена обновления tokenHash и удаляет истекшие \\\
+	Save(ctx context.Context, sessionID string, tokenHash []byte, expiresAt time.Time) error
+	/// Rotate заменяет хэш токена oldHash сессии sessionID на newHash. Если сессии нет, она истекла или токен уже заменен, \\\
+	/// сессия удаляется и возвращается apperror.ErrNotFound \\\
+	Rotate(ctx context.Context, sessionID string, oldHash, newHash []byte, expiresAt time.Time) error
+	/// Delete удаляет сессию sessionID \\\
+	Delete(ctx context.Context, sessionID string) error
+}
+
+/// Функция hashRefreshToken хэширует токен обновления: в хранилище сессий сам токен не попадает \\\
+
+func hashRefreshToken(refreshToken string) []byte {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return sum[:]
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"context"
+	"crypto/subtle"
+	"sync"
+	"time"
+)
+
+var _ SessionStorage = &MemorySessionStorage{}
+
+/// Структура MemorySessionStorage хранящая сессии входа в памяти процесса (режим "memory") \\\
+
+type MemorySessionStorage struct {
+	mu       sync.Mutex
+	sessions map[string]memorySession
+	now      func() time.Time
+}
+
+/// Структура memorySession - хэш действующего токена обновления сессии и срок ее жизни \\\
+
+type memorySession struct {
+	tokenHash []byte
+	expiresAt time.Time
+}
+
+/// Структура NewMemorySessionStorage возвращает новый пустой экземпляр MemorySessionStorage \\\
+
+func NewMemorySessionStorage() SessionStorage {
+	return &MemorySessionStorage{
+		sessions: make(map[string]memorySession),
+		now:      time.Now,
+	}
+}
+
+/// Функция Save сохраняет сессию sessionID и удаляет истекшие, чтобы память не росла \\\
+
+func (d *MemorySessionStorage) Save(ctx context.Context, sessionID string, tokenHash []byte, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for id, s := range d.sessions {
+		if !now.Before(s.expiresAt) {
+			delete(d.sessions, id)
+		}
+	}
+	d.sessions[sessionID] = memorySession{tokenHash: tokenHash, expiresAt: expiresAt}
+	return nil
+}
+
+/// Функция Rotate заменяет хэш токена обновления сессии sessionID, если предъявлен действующий токен, иначе удаляет сессию \\\
+
+func (d *MemorySessionStorage) Rotate(ctx context.Context, sessionID string, oldHash, newHash []byte, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, ok := d.sessions[sessionID]
+	if !ok || !d.now().Before(s.expiresAt) || subtle.ConstantTimeCompare(s.tokenHash, oldHash) != 1 {
+		delete(d.sessions, sessionID)
+		return apperror.ErrNotFound
+	}
+	d.sessions[sessionID] = memorySession{tokenHash: newHash, expiresAt: expiresAt}
+	return nil
+}
+
+/// Функция Delete удаляет сессию sessionID \\\
+
+func (d *MemorySessionStorage) Delete(ctx context.Context, sessionID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.sessions, sessionID)
+	return nil
+}
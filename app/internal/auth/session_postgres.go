@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"Interior_Visualization_Shop/app/pkg/tracing"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+var _ SessionStorage = &PostgresSessionStorage{}
+
+/// Структура PostgresSessionStorage хранящая сессии входа в таблице refresh_session \\\
+
+type PostgresSessionStorage struct {
+	conn           *pgxpool.Pool
+	requestTimeout time.Duration
+}
+
+/// Структура NewPostgresSessionStorage возвращает новый экземпляр PostgresSessionStorage инициализируя переданные в него аргументы \\\
+
+func NewPostgresSessionStorage(conn *pgxpool.Pool, requestTimeout int) SessionStorage {
+	return &PostgresSessionStorage{
+		conn:           conn,
+		requestTimeout: time.Duration(requestTimeout) * time.Second,
+	}
+}
+
+/// Функция Save сохраняет сессию sessionID и удаляет истекшие \\\
+
+func (d *PostgresSessionStorage) Save(ctx context.Context, sessionID string, tokenHash []byte, expiresAt time.Time) error {
+	ctx, span := tracing.StartQuery(ctx, "save_refresh_session")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := d.conn.Exec(ctx, `DELETE FROM refresh_session WHERE expires_at <= now()`)
+	if err == nil {
+		_, err = d.conn.Exec(ctx,
+			`INSERT INTO refresh_session (session_id, token_hash, expires_at) VALUES($1,$2,$3)
+				 ON CONFLICT (session_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, expires_at = EXCLUDED.expires_at`,
+			sessionID, tokenHash, expiresAt)
+	}
+	metrics.ObserveQuery("save_refresh_session", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to save refresh session: %v", err)
+	}
+	return nil
+}
+
+/// Функция Rotate одним запросом заменяет хэш действующего токена обновления сессии sessionID. \\\
+/// Если ни одна строка не изменилась, токен уже заменен или сессия истекла, и сессия удаляется \\\
+
+func (d *PostgresSessionStorage) Rotate(ctx context.Context, sessionID string, oldHash, newHash []byte, expiresAt time.Time) error {
+	ctx, span := tracing.StartQuery(ctx, "rotate_refresh_session")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	tag, err := d.conn.Exec(ctx,
+		`UPDATE refresh_session SET token_hash = $3, expires_at = $4
+			 WHERE session_id = $1 AND token_hash = $2 AND expires_at > now()`,
+		sessionID, oldHash, newHash, expiresAt)
+	rotated := err == nil && tag.RowsAffected() == 1
+	if err == nil && !rotated {
+		_, err = d.conn.Exec(ctx, `DELETE FROM refresh_session WHERE session_id = $1`, sessionID)
+	}
+	metrics.ObserveQuery("rotate_refresh_session", start, err)
+	tracing.RecordError(span, err)
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to rotate refresh session: %v", err)
+	case !rotated:
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+/// Функция Delete удаляет сессию sessionID \\\
+
+func (d *PostgresSessionStorage) Delete(ctx context.Context, sessionID string) error {
+	ctx, span := tracing.StartQuery(ctx, "delete_refresh_session")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := d.conn.Exec(ctx, `DELETE FROM refresh_session WHERE session_id = $1`, sessionID)
+	metrics.ObserveQuery("delete_refresh_session", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to delete refresh session: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"testing"
+	"time"
+)
+
+/// Функция testSessionStorage - общий набор проверок, который проходит каждая реализация SessionStorage \\\
+
+func testSessionStorage(t *testing.T, s SessionStorage) {
+	ctx := context.Background()
+	const id = "conformance-session"
+	expires := time.Now().Add(time.Hour)
+
+	if err := s.Rotate(ctx, id, []byte("first"), []byte("second"), expires); !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("Rotate before Save: got %v, want ErrNotFound", err)
+	}
+	if err := s.Save(ctx, id, []byte("first"), expires); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := s.Rotate(ctx, id, []byte("first"), []byte("second"), expires); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if err := s.Rotate(ctx, id, []byte("second"), []byte("third"), expires); err != nil {
+		t.Fatalf("Rotate the new token: %v", err)
+	}
+
+	/// Повторно предъявленный замененный токен завершает сессию, и действующий токен тоже перестает подходить \\\
+	if err := s.Rotate(ctx, id, []byte("second"), []byte("stolen"), expires); !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("Rotate a replaced token: got %v, want ErrNotFound", err)
+	}
+	if err := s.Rotate(ctx, id, []byte("third"), []byte("fourth"), expires); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("Rotate after reuse: got %v, want ErrNotFound", err)
+	}
+
+	if err := s.Save(ctx, id, []byte("expired"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Save expired: %v", err)
+	}
+	if err := s.Rotate(ctx, id, []byte("expired"), []byte("next"), expires); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("Rotate an expired session: got %v, want ErrNotFound", err)
+	}
+
+	if err := s.Save(ctx, id, []byte("deleted"), expires); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := s.Delete(ctx, id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Rotate(ctx, id, []byte("deleted"), []byte("next"), expires); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("Rotate after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemorySessionStorage(t *testing.T) {
+	testSessionStorage(t, NewMemorySessionStorage())
+}
+
+/// Функция TestPostgresSessionStorage прогоняет набор на настоящей базе, если задана TEST_DATABASE_DSN \\\
+
+func TestPostgresSessionStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	cleanup := func() {
+		if _, err := conn.Exec(ctx, `DELETE FROM refresh_session WHERE session_id = 'conformance-session'`); err != nil {
+			t.Fatalf("cleanup: %v", err)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	testSessionStorage(t, NewPostgresSessionStorage(conn, 5))
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/pkg/config"
+	"Interior_Visualization_Shop/app/pkg/token"
+	"fmt"
+	"time"
+)
+
+/// Суффикс аудитории токенов обновления: без него при общей аудитории и секрете токен доступа прошел бы как токен обновления \\\
+
+const refreshAudienceSuffix = "/refresh"
+
+/// Структура Tokens - выпуск и проверка токенов доступа и обновления \\\
+
+type Tokens struct {
+	Access  *token.JWT
+	Refresh *token.JWT
+}
+
+/// Функция NewTokens разбирает ключи JWT из конфигурации, чтобы ошибка в ключе остановила запуск, а не первый вход пользователя. \\\
+/// Токены обновления проверяет только этот сервис, поэтому они всегда подписываются общим секретом HS256 \\\
+/// и адресованы ему самому отдельной аудиторией <issuer>/refresh \\\
+
+func NewTokens(cfg config.Config) (*Tokens, error) {
+	accessKeys, err := cfg.AccessTokenKeys()
+	if err != nil {
+		return nil, fmt.Errorf("access token keys: %v", err)
+	}
+	access, err := token.NewKeySet(cfg.JWT.Algorithm, tokenKeys(accessKeys))
+	if err != nil {
+		return nil, fmt.Errorf("access token keys: %v", err)
+	}
+	refreshKeys, err := cfg.RefreshTokenKeys()
+	if err != nil {
+		return nil, fmt.Errorf("refresh token keys: %v", err)
+	}
+	refresh, err := token.NewKeySet(token.HS256, tokenKeys(refreshKeys))
+	if err != nil {
+		return nil, fmt.Errorf("refresh token keys: %v", err)
+	}
+	return &Tokens{
+		Access: token.New(access, token.Options{
+			Issuer:   cfg.JWT.Issuer,
+			Audience: cfg.JWT.Audience,
+			TTL:      time.Duration(cfg.JWT.AccessExpirationMinutes) * time.Minute,
+		}),
+		Refresh: token.New(refresh, token.Options{
+			Issuer:   cfg.JWT.Issuer,
+			Audience: cfg.JWT.Issuer + refreshAudienceSuffix,
+			TTL:      time.Duration(cfg.JWT.RefreshExpirationDays) * 24 * time.Hour,
+		}),
+	}, nil
+}
+
+func tokenKeys(keys []config.SigningKey) []token.Key {
+	out := make([]token.Key, len(keys))
+	for i, k := range keys {
+		out[i] = token.Key{ID: k.ID, Material: []byte(k.Secret.Value())}
+	}
+	return out
+}
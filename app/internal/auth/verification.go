@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/internal/normalize"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+/// Длина случайной части ссылки подтверждения в байтах \\\
+
+const magicTokenBytes = 32
+
+/// Функция GenerateCode возвращает код подтверждения длины length из символов alphabet. \\\
+/// Символы выбираются через crypto/rand равновероятно, без смещения по модулю \\\
+
+func GenerateCode(length int, alphabet string) (string, error) {
+	symbols := []rune(alphabet)
+	if length < 1 || len(symbols) < 2 {
+		return "", fmt.Errorf("invalid code settings: length %d, alphabet %q", length, alphabet)
+	}
+
+	max := big.NewInt(int64(len(symbols)))
+	code := make([]rune, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = symbols[n.Int64()]
+	}
+	return string(code), nil
+}
+
+/// Функция newMagicToken возвращает случайный токен для ссылки подтверждения \\\
+
+func newMagicToken() (string, error) {
+	b := make([]byte, magicTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+/// Функция hashSecret хэширует код или токен вместе с солью записи \\\
+
+func hashSecret(salt []byte, secret string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(secret))
+	return h.Sum(nil)
+}
+
+/// Структура PendingRegistration - регистрация, ожидающая подтверждения почты. \\\
+/// Пароль хранится только bcrypt хэшем, код и токен ссылки - только хэшами SHA-256 \\\
+
+type PendingRegistration struct {
+	Email        string `log:"email"`
+	Name         string
+	Surname      string
+	PasswordHash string `log:"secret"`
+	Salt         []byte `log:"secret"`
+	CodeHash     []byte `log:"secret"`
+	TokenHash    []byte `log:"secret"`
+	ExpiresAt    time.Time
+	Attempts     int
+}
+
+func (p PendingRegistration) String() string   { return logger.Redacted(p) }
+func (p PendingRegistration) GoString() string { return logger.Redacted(p) }
+
+/// Интерфейс VerificationStorage - хранилище регистраций, ожидающих подтверждения. \\\
+/// Регистрации переживают перезапуск и видны всем экземплярам сервера, если хранилище общее \\\
+
+type VerificationStorage interface {
+	/// Save сохраняет регистрацию, заменяя прежнюю для того же адреса, и удаляет истекшие \\\
+	Save(ctx context.Context, p *PendingRegistration) error
+	/// FindByEmail возвращает регистрацию адреса email или apperror.ErrNotFound \\\
+	FindByEmail(ctx context.Context, email string) (*PendingRegistration, error)
+	/// TakeByToken удаляет и возвращает регистрацию с хэшем токена tokenHash, так что ссылка срабатывает один раз \\\
+	TakeByToken(ctx context.Context, tokenHash []byte) (*PendingRegistration, error)
+	/// AddAttempt учитывает неверный код для адреса email и возвращает число неверных попыток \\\
+	AddAttempt(ctx context.Context, email string) (int, error)
+	/// Delete удаляет регистрацию адреса email \\\
+	Delete(ctx context.Context, email string) error
+}
+
+/// Структура verifier проверяет коды и ссылки подтверждения по регистрациям из хранилища \\\
+
+type verifier struct {
+	storage     VerificationStorage
+	ttl         time.Duration
+	maxAttempts int
+	now         func() time.Time
+}
+
+/// Структура newVerifier возвращает новый экземпляр verifier инициализируя переданные в него аргументы \\\
+
+func newVerifier(storage VerificationStorage, ttl time.Duration, maxAttempts int) *verifier {
+	return &verifier{
+		storage:     storage,
+		ttl:         ttl,
+		maxAttempts: maxAttempts,
+		now:         time.Now,
+	}
+}
+
+/// Функция put сохраняет регистрацию input с уже захэшированным паролем и хэшами code и token, \\\
+/// заменяя прежнюю для того же адреса \\\
+
+func (v *verifier) put(ctx context.Context, input Register, code, token string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	return v.storage.Save(ctx, &PendingRegistration{
+		Email:        normalize.Email(input.Email),
+		Name:         input.Name,
+		Surname:      input.Surname,
+		PasswordHash: input.Password,
+		Salt:         salt,
+		CodeHash:     hashSecret(salt, code),
+		TokenHash:    hashSecret(nil, token),
+		ExpiresAt:    v.now().Add(v.ttl),
+	})
+}
+
+/// Функция checkCode проверяет код для адреса email и при совпадении возвращает данные регистрации. \\\
+/// После maxAttempts неверных попыток запись удаляется и нужно запросить новый код \\\
+
+func (v *verifier) checkCode(ctx context.Context, email, code string) (*Register, error) {
+	email = normalize.Email(email)
+	p, err := v.storage.FindByEmail(ctx, email)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, apperror.ErrMailCodeExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !v.now().Before(p.ExpiresAt) {
+		return nil, v.reject(ctx, email, apperror.ErrMailCodeExpired)
+	}
+
+	if subtle.ConstantTimeCompare(p.CodeHash, hashSecret(p.Salt, code)) != 1 {
+		attempts, err := v.storage.AddAttempt(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= v.maxAttempts {
+			return nil, v.reject(ctx, email, apperror.ErrTooManyAttempts)
+		}
+		return nil, apperror.ErrInvalidMailCode
+	}
+
+	if err = v.storage.Delete(ctx, email); err != nil {
+		return nil, err
+	}
+	return p.register(), nil
+}
+
+/// Функция checkToken находит регистрацию по токену из ссылки подтверждения \\\
+
+func (v *verifier) checkToken(ctx context.Context, token string) (*Register, error) {
+	p, err := v.storage.TakeByToken(ctx, hashSecret(nil, token))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, apperror.ErrMailCodeExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !v.now().Before(p.ExpiresAt) {
+		return nil, apperror.ErrMailCodeExpired
+	}
+	return p.register(), nil
+}
+
+/// Функция reject удаляет регистрацию email и возвращает ошибку проверки cause \\\
+
+func (v *verifier) reject(ctx context.Context, email string, cause error) error {
+	if err := v.storage.Delete(ctx, email); err != nil {
+		return err
+	}
+	return cause
+}
+
+/// Функция register возвращает данные регистрации для создания пользователя, пароль в них уже хэш \\\
+
+func (p *PendingRegistration) register() *Register {
+	return &Register{
+		Email:    p.Email,
+		Name:     p.Name,
+		Surname:  p.Surname,
+		Password: p.PasswordHash,
+	}
+}
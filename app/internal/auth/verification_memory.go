@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"context"
+	"sync"
+	"time"
+)
+
+var _ VerificationStorage = &MemoryVerificationStorage{}
+
+/// Структура MemoryVerificationStorage хранящая ожидающие подтверждения регистрации в памяти процесса (режим "memory") \\\
+
+type MemoryVerificationStorage struct {
+	mu      sync.Mutex
+	byEmail map[string]PendingRegistration
+	byToken map[string]string
+	now     func() time.Time
+}
+
+/// Структура NewMemoryVerificationStorage возвращает новый пустой экземпляр MemoryVerificationStorage \\\
+
+func NewMemoryVerificationStorage() VerificationStorage {
+	return &MemoryVerificationStorage{
+		byEmail: make(map[string]PendingRegistration),
+		byToken: make(map[string]string),
+		now:     time.Now,
+	}
+}
+
+/// Функция Save сохраняет регистрацию p, заменяя прежнюю для того же адреса, и удаляет истекшие, чтобы память не росла \\\
+
+func (d *MemoryVerificationStorage) Save(ctx context.Context, p *PendingRegistration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for email, old := range d.byEmail {
+		if !now.Before(old.ExpiresAt) {
+			d.remove(email)
+		}
+	}
+	d.remove(p.Email)
+	d.byEmail[p.Email] = *p
+	d.byToken[string(p.TokenHash)] = p.Email
+	return nil
+}
+
+/// Функция FindByEmail возвращает регистрацию адреса email \\\
+
+func (d *MemoryVerificationStorage) FindByEmail(ctx context.Context, email string) (*PendingRegistration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.byEmail[email]
+	if !ok {
+		return nil, apperror.ErrNotFound
+	}
+	return &p, nil
+}
+
+/// Функция TakeByToken удаляет и возвращает регистрацию с хэшем токена tokenHash \\\
+
+func (d *MemoryVerificationStorage) TakeByToken(ctx context.Context, tokenHash []byte) (*PendingRegistration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	email, ok := d.byToken[string(tokenHash)]
+	if !ok {
+		return nil, apperror.ErrNotFound
+	}
+	p := d.byEmail[email]
+	d.remove(email)
+	return &p, nil
+}
+
+/// Функция AddAttempt учитывает неверный код для адреса email \\\
+
+func (d *MemoryVerificationStorage) AddAttempt(ctx context.Context, email string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.byEmail[email]
+	if !ok {
+		return 0, apperror.ErrNotFound
+	}
+	p.Attempts++
+	d.byEmail[email] = p
+	return p.Attempts, nil
+}
+
+/// Функция Delete удаляет регистрацию адреса email \\\
+
+func (d *MemoryVerificationStorage) Delete(ctx context.Context, email string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.remove(email)
+	return nil
+}
+
+/// Функция remove удаляет регистрацию email вместе с ее токеном, вызывается под mu \\\
+
+func (d *MemoryVerificationStorage) remove(email string) {
+	if p, ok := d.byEmail[email]; ok {
+		delete(d.byToken, string(p.TokenHash))
+		delete(d.byEmail, email)
+	}
+}
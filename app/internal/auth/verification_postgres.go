@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"Interior_Visualization_Shop/app/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+var _ VerificationStorage = &PostgresVerificationStorage{}
+
+/// Структура PostgresVerificationStorage хранящая ожидающие подтверждения регистрации в таблице pending_registration \\\
+
+type PostgresVerificationStorage struct {
+	conn           *pgxpool.Pool
+	requestTimeout time.Duration
+}
+
+/// Структура NewPostgresVerificationStorage возвращает новый экземпляр PostgresVerificationStorage инициализируя переданные в него аргументы \\\
+
+func NewPostgresVerificationStorage(conn *pgxpool.Pool, requestTimeout int) VerificationStorage {
+	return &PostgresVerificationStorage{
+		conn:           conn,
+		requestTimeout: time.Duration(requestTimeout) * time.Second,
+	}
+}
+
+/// Столбцы pending_registration в порядке полей, которые заполняет scanPending \\\
+
+const pendingColumns = `email, name, surname, password_hash, salt, code_hash, token_hash, expires_at, attempts`
+
+/// Функция Save сохраняет регистрацию p, заменяя прежнюю для того же адреса, и удаляет истекшие \\\
+
+func (d *PostgresVerificationStorage) Save(ctx context.Context, p *PendingRegistration) error {
+	ctx, span := tracing.StartQuery(ctx, "save_pending_registration")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := d.conn.Exec(ctx, `DELETE FROM pending_registration WHERE expires_at <= now()`)
+	if err == nil {
+		_, err = d.conn.Exec(ctx,
+			`INSERT INTO pending_registration (`+pendingColumns+`)
+				 VALUES($1,$2,$3,$4,$5,$6,$7,$8,0)
+				 ON CONFLICT (email) DO UPDATE SET
+				 name = EXCLUDED.name, surname = EXCLUDED.surname, password_hash = EXCLUDED.password_hash,
+				 salt = EXCLUDED.salt, code_hash = EXCLUDED.code_hash, token_hash = EXCLUDED.token_hash,
+				 expires_at = EXCLUDED.expires_at, attempts = 0`,
+			p.Email, p.Name, p.Surname, p.PasswordHash, p.Salt, p.CodeHash, p.TokenHash, p.ExpiresAt)
+	}
+	metrics.ObserveQuery("save_pending_registration", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to save pending registration: %v", err)
+	}
+	return nil
+}
+
+/// Функция FindByEmail возвращает регистрацию адреса email \\\
+
+func (d *PostgresVerificationStorage) FindByEmail(ctx context.Context, email string) (*PendingRegistration, error) {
+	ctx, span := tracing.StartQuery(ctx, "find_pending_registration")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	p, err := scanPending(d.conn.QueryRow(ctx,
+		`SELECT `+pendingColumns+` FROM pending_registration WHERE email = $1`, email))
+	metrics.ObserveQuery("find_pending_registration", start, err)
+	tracing.RecordError(span, err)
+	return p, pendingError("find pending registration", err)
+}
+
+/// Функция TakeByToken удаляет и возвращает регистрацию с хэшем токена tokenHash одним запросом \\\
+
+func (d *PostgresVerificationStorage) TakeByToken(ctx context.Context, tokenHash []byte) (*PendingRegistration, error) {
+	ctx, span := tracing.StartQuery(ctx, "take_pending_registration")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	p, err := scanPending(d.conn.QueryRow(ctx,
+		`DELETE FROM pending_registration WHERE token_hash = $1 RETURNING `+pendingColumns, tokenHash))
+	metrics.ObserveQuery("take_pending_registration", start, err)
+	tracing.RecordError(span, err)
+	return p, pendingError("take pending registration", err)
+}
+
+/// Функция AddAttempt атомарно учитывает неверный код для адреса email \\\
+
+func (d *PostgresVerificationStorage) AddAttempt(ctx context.Context, email string) (int, error) {
+	ctx, span := tracing.StartQuery(ctx, "add_pending_registration_attempt")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var attempts int
+	err := d.conn.QueryRow(ctx,
+		`UPDATE pending_registration SET attempts = attempts + 1
+			 WHERE email = $1 RETURNING attempts`, email).Scan(&attempts)
+	metrics.ObserveQuery("add_pending_registration_attempt", start, err)
+	tracing.RecordError(span, err)
+	return attempts, pendingError("add pending registration attempt", err)
+}
+
+/// Функция Delete удаляет регистрацию адреса email \\\
+
+func (d *PostgresVerificationStorage) Delete(ctx context.Context, email string) error {
+	ctx, span := tracing.StartQuery(ctx, "delete_pending_registration")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := d.conn.Exec(ctx, `DELETE FROM pending_registration WHERE email = $1`, email)
+	metrics.ObserveQuery("delete_pending_registration", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to delete pending registration: %v", err)
+	}
+	return nil
+}
+
+/// Функция scanPending читает регистрацию из строки со столбцами pendingColumns \\\
+
+func scanPending(row pgx.Row) (*PendingRegistration, error) {
+	var p PendingRegistration
+	err := row.Scan(&p.Email, &p.Name, &p.Surname, &p.PasswordHash, &p.Salt, &p.CodeHash, &p.TokenHash, &p.ExpiresAt, &p.Attempts)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+/// Функция pendingError переводит отсутствие строки в apperror.ErrNotFound, а прочие ошибки запроса op дополняет контекстом \\\
+
+func pendingError(op string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return apperror.ErrNotFound
+	default:
+		return fmt.Errorf("failed to %s: %v", op, err)
+	}
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"testing"
+	"time"
+)
+
+/// Функция testVerificationStorage - общий набор проверок, который проходит каждая реализация VerificationStorage \\\
+
+func testVerificationStorage(t *testing.T, s VerificationStorage) {
+	ctx := context.Background()
+	const email = "conformance@mail.test"
+	pending := func(token string) *PendingRegistration {
+		return &PendingRegistration{
+			Email: email, Name: "Maksim", Surname: "Petrov", PasswordHash: "$2a$10$hash",
+			Salt: []byte("salt"), CodeHash: []byte("code"), TokenHash: []byte(token),
+			ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second),
+		}
+	}
+
+	if _, err := s.FindByEmail(ctx, email); !errors.Is(err, apperror.ErrNotFound) {
+		t.Fatalf("FindByEmail before Save: got %v, want ErrNotFound", err)
+	}
+	if err := s.Save(ctx, pending("conformance-first")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	for want := 1; want <= 2; want++ {
+		if got, err := s.AddAttempt(ctx, email); err != nil || got != want {
+			t.Errorf("AddAttempt #%d: got %d, %v", want, got, err)
+		}
+	}
+
+	/// Повторное сохранение заменяет регистрацию, ее токен и счетчик попыток \\\
+	want := pending("conformance-second")
+	if err := s.Save(ctx, want); err != nil {
+		t.Fatalf("Save again: %v", err)
+	}
+	got, err := s.FindByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	if got.Name != want.Name || got.PasswordHash != want.PasswordHash || !bytes.Equal(got.Salt, want.Salt) ||
+		!bytes.Equal(got.TokenHash, want.TokenHash) || !got.ExpiresAt.Equal(want.ExpiresAt) || got.Attempts != 0 {
+		t.Errorf("FindByEmail: got %#v, want %#v", got, want)
+	}
+	if _, err = s.TakeByToken(ctx, []byte("conformance-first")); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("TakeByToken with the replaced token: got %v, want ErrNotFound", err)
+	}
+
+	if got, err = s.TakeByToken(ctx, want.TokenHash); err != nil || got.Email != email {
+		t.Fatalf("TakeByToken: got %v, %v", got, err)
+	}
+	if _, err = s.TakeByToken(ctx, want.TokenHash); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("TakeByToken twice: got %v, want ErrNotFound", err)
+	}
+
+	if err = s.Save(ctx, pending("conformance-third")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err = s.Delete(ctx, email); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err = s.FindByEmail(ctx, email); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("FindByEmail after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryVerificationStorage(t *testing.T) {
+	testVerificationStorage(t, NewMemoryVerificationStorage())
+}
+
+/// Функция TestPostgresVerificationStorage прогоняет набор на настоящей базе, если задана TEST_DATABASE_DSN \\\
+
+func TestPostgresVerificationStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	cleanup := func() {
+		if _, err := conn.Exec(ctx, `DELETE FROM pending_registration WHERE email = 'conformance@mail.test'`); err != nil {
+			t.Fatalf("cleanup: %v", err)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	testVerificationStorage(t, NewPostgresVerificationStorage(conn, 5))
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"context"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		code, err := GenerateCode(8, "ABCDEFGHJKMNPQRSTUVWXYZ23456789")
+		if err != nil {
+			t.Fatalf("GenerateCode: %v", err)
+		}
+		if len(code) != 8 || strings.Trim(code, "ABCDEFGHJKMNPQRSTUVWXYZ23456789") != "" {
+			t.Fatalf("GenerateCode: got %q", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 95 {
+		t.Errorf("GenerateCode: only %d distinct codes out of 100", len(seen))
+	}
+
+	for _, tc := range []struct {
+		length   int
+		alphabet string
+	}{
+		{0, "0123456789"},
+		{6, "0"},
+		{6, ""},
+	} {
+		if _, err := GenerateCode(tc.length, tc.alphabet); err == nil {
+			t.Errorf("GenerateCode(%d, %q): got nil error", tc.length, tc.alphabet)
+		}
+	}
+}
+
+func TestVerificationKeepsOnlyHashes(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryVerificationStorage()
+	v := newVerifier(storage, time.Minute, 3)
+	input := Register{Email: "Petrov@mail.ru", Name: "Maksim", Surname: "Petrov", Password: "abcdEFG1"}
+	if err := input.HashPassword(); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.put(ctx, input, "482913", "magic-token"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	p, err := storage.FindByEmail(ctx, "petrov@mail.ru")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	for _, stored := range []string{p.PasswordHash, string(p.CodeHash), string(p.TokenHash), p.String()} {
+		for _, secret := range []string{"abcdEFG1", "482913", "magic-token"} {
+			if strings.Contains(stored, secret) {
+				t.Errorf("put: %q is stored in plain text", secret)
+			}
+		}
+	}
+
+	register, err := v.checkCode(ctx, "petrov@mail.ru", "482913")
+	if err != nil {
+		t.Fatalf("checkCode: %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(register.Password), []byte("abcdEFG1")) != nil {
+		t.Error("checkCode: the registration lost the password hash")
+	}
+	if _, err = storage.FindByEmail(ctx, "petrov@mail.ru"); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("checkCode: the registration is kept after confirmation: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package catalog
+
+/// Структура Service - услуга из каталога, цена хранится текстом в валюте сайта \\\
+
+type Service struct {
+	ID          int64  `json:"id" example:"1"`
+	Name        string `json:"name" example:"Interior visualization"`
+	Description string `json:"description" example:"Photorealistic renders of your interior"`
+	Price       string `json:"price" example:"10000"`
+	Image       string `json:"image" example:"5.jpg"`
+}
+
+/// Структура Work - работа из портфолио \\\
+
+type Work struct {
+	ID          int64  `json:"id" example:"1"`
+	Title       string `json:"title" example:"Living room"`
+	Description string `json:"description" example:"Scandinavian style"`
+	Image       string `json:"image" example:"1.jpg"`
+}
+
+/// Функция DefaultServices возвращает услуги, которые показывает сайт без БД (режим "memory") \\\
+
+func DefaultServices() []Service {
+	return []Service{
+		{ID: 1, Name: "Creation of 3d models", Description: "Detailed 3D models of furniture, decor and whole rooms", Price: "5000", Image: "1.jpg"},
+		{ID: 2, Name: "Animation", Description: "Walkthrough videos that show the interior from every angle", Price: "15000", Image: "4.jpg"},
+		{ID: 3, Name: "Interior visualization", Description: "Photorealistic renders of your future interior", Price: "10000", Image: "5.jpg"},
+	}
+}
+
+/// Функция DefaultWorks возвращает работы портфолио, которые показывает сайт без БД (режим "memory") \\\
+
+func DefaultWorks() []Work {
+	images := []string{"1.jpg", "2.jpg", "3.jpg", "8.jpg", "10.jpg", "9.jpg", "5.jpg"}
+	works := make([]Work, len(images))
+	for i, image := range images {
+		works[i] = Work{ID: int64(i + 1), Title: "creatively designed", Description: "Lorem ipsum dolor sit", Image: image}
+	}
+	return works
+}
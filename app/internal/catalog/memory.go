@@ -0,0 +1,54 @@
+package catalog
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"sync"
+)
+
+var _ Storage = &MemoryStorage{}
+
+/// Структура MemoryStorage хранящая каталог в памяти процесса (режим "memory" для демонстраций и тестов) \\\
+
+type MemoryStorage struct {
+	log      logger.Logger
+	mu       sync.RWMutex
+	services []Service
+	works    []Work
+}
+
+/// Структура NewMemoryStorage возвращает новый экземпляр MemoryStorage с услугами services и работами works \\\
+
+func NewMemoryStorage(services []Service, works []Work) Storage {
+	return &MemoryStorage{
+		log:      logger.GetLogger(),
+		services: services,
+		works:    works,
+	}
+}
+
+/// Функция Services для сущности MemoryStorage возвращает копию списка услуг \\\
+
+func (d *MemoryStorage) Services(ctx context.Context) ([]Service, error) {
+	d.log.FromContext(ctx).Info("MEMORY: GET SERVICES")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]Service(nil), d.services...), nil
+}
+
+/// Функция Works для сущности MemoryStorage возвращает копию списка работ портфолио \\\
+
+func (d *MemoryStorage) Works(ctx context.Context) ([]Work, error) {
+	d.log.FromContext(ctx).Info("MEMORY: GET WORKS")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]Work(nil), d.works...), nil
+}
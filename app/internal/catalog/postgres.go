@@ -0,0 +1,108 @@
+package catalog
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"Interior_Visualization_Shop/app/pkg/tracing"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+var _ Storage = &CatalogStorage{}
+
+/// Структура CatalogStorage содержащая поля для работы с БД \\\
+
+type CatalogStorage struct {
+	log            logger.Logger
+	conn           *pgxpool.Pool
+	requestTimeout time.Duration
+}
+
+/// Структура NewStorage возвращает новый экземпляр CatalogStorage инициализируя переданные в него аргументы. \\\
+/// Каталог читается при каждом показе страниц сайта, поэтому запросы идут через пул соединений \\\
+
+func NewStorage(storage *pgxpool.Pool, requestTimeout int) Storage {
+	return &CatalogStorage{
+		log:            logger.GetLogger(),
+		conn:           storage,
+		requestTimeout: time.Duration(requestTimeout) * time.Second,
+	}
+}
+
+/// Функция Services для сущности CatalogStorage получает услуги из БД в порядке показа на сайте \\\
+
+func (d *CatalogStorage) Services(ctx context.Context) ([]Service, error) {
+	d.log.FromContext(ctx).Info("POSTGRES: GET SERVICES")
+	ctx, span := tracing.StartQuery(ctx, "find_services")
+	defer span.End()
+
+	/// Ограничение времени выполнения запроса, не превышающее срок контекста вызывающей стороны \\\
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	/// Выполнение запроса к БД и сканирование строк \\\
+	start := time.Now()
+	var services []Service
+	rows, err := d.conn.Query(ctx,
+		`SELECT id, name_service, description, price, image FROM service
+			 ORDER BY position, id`)
+	if err == nil {
+		for rows.Next() {
+			var s Service
+			if err = rows.Scan(&s.ID, &s.Name, &s.Description, &s.Price, &s.Image); err != nil {
+				break
+			}
+			services = append(services, s)
+		}
+		rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+	}
+	metrics.ObserveQuery("find_services", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute find services query: %v", err)
+	}
+	return services, nil
+}
+
+/// Функция Works для сущности CatalogStorage получает работы портфолио из БД в порядке показа на сайте \\\
+
+func (d *CatalogStorage) Works(ctx context.Context) ([]Work, error) {
+	d.log.FromContext(ctx).Info("POSTGRES: GET WORKS")
+	ctx, span := tracing.StartQuery(ctx, "find_works")
+	defer span.End()
+
+	/// Ограничение времени выполнения запроса, не превышающее срок контекста вызывающей стороны \\\
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	/// Выполнение запроса к БД и сканирование строк \\\
+	start := time.Now()
+	var works []Work
+	rows, err := d.conn.Query(ctx,
+		`SELECT id, title, description, image FROM portfolio
+			 ORDER BY position, id`)
+	if err == nil {
+		for rows.Next() {
+			var w Work
+			if err = rows.Scan(&w.ID, &w.Title, &w.Description, &w.Image); err != nil {
+				break
+			}
+			works = append(works, w)
+		}
+		rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+	}
+	metrics.ObserveQuery("find_works", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute find works query: %v", err)
+	}
+	return works, nil
+}
@@ -0,0 +1,8 @@
+package catalog
+
+import "context"
+
+type Storage interface {
+	Services(ctx context.Context) ([]Service, error)
+	Works(ctx context.Context) ([]Work, error)
+}
@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"testing"
+)
+
+/// Функция testStorage - общий набор проверок, который проходит каждая реализация Storage \\\
+
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+
+	services, err := s.Services(ctx)
+	if err != nil {
+		t.Fatalf("Services: %v", err)
+	}
+	if len(services) == 0 || services[0].Name == "" || services[0].Image == "" {
+		t.Fatalf("Services: got %+v, want the seeded catalog", services)
+	}
+
+	works, err := s.Works(ctx)
+	if err != nil {
+		t.Fatalf("Works: %v", err)
+	}
+	if len(works) == 0 || works[0].Image == "" {
+		t.Fatalf("Works: got %+v, want the seeded portfolio", works)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = s.Services(canceled); err == nil {
+		t.Error("Services with a canceled context: got nil error")
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, NewMemoryStorage(DefaultServices(), DefaultWorks()))
+}
+
+/// Функция TestPostgresStorage прогоняет набор на настоящей базе, заполненной db.sql, если задана TEST_DATABASE_DSN \\\
+
+func TestPostgresStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	testStorage(t, NewStorage(conn, 5))
+}
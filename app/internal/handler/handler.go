@@ -1,7 +1,7 @@
 package handler
 
 import (
-	"fmt"
+	"Interior_Visualization_Shop/app/internal/apperror"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 	"strconv"
@@ -15,7 +15,7 @@ func ReadIdParam64(r *http.Request) (int64, error) {
 	params := httprouter.ParamsFromContext(r.Context())
 	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
 	if err != nil || id < 1 {
-		return 0, fmt.Errorf("id must have type int64")
+		return 0, apperror.Validation(apperror.FieldError{Field: "id", Message: "must be a positive integer"})
 	}
 	return id, nil
 }
@@ -0,0 +1,126 @@
+package health
+
+import (
+	"Interior_Visualization_Shop/app/internal/handler"
+	"Interior_Visualization_Shop/app/internal/response"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	healthURL    = "/healthz"
+	readinessURL = "/readyz"
+
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+	statusError       = "error"
+)
+
+/// Тип Check проверяет доступность одной зависимости приложения \\\
+
+type Check func(ctx context.Context) error
+
+/// Структура CheckResult - результат проверки одной зависимости \\\
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+/// Структура Report - ответ эндпоинта готовности \\\
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+/// Структура Handler представляющая собой обработчик проверок живости и готовности \\\
+
+type Handler struct {
+	log     logger.Logger
+	checks  map[string]Check
+	timeout time.Duration
+}
+
+/// Структура NewHandler возвращает новый экземпляр Handler с проверками checks по именам зависимостей \\\
+
+func NewHandler(log logger.Logger, checks map[string]Check, timeout time.Duration) handler.Hand {
+	return &Handler{
+		log:     log,
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+/// Структура Register регистрирует эндпоинты /healthz и /readyz \\\
+
+func (h *Handler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, healthURL, h.Health)
+	router.HandlerFunc(http.MethodGet, readinessURL, h.Ready)
+}
+
+/// Функция Health сообщает, что процесс запущен и обрабатывает запросы \\\
+
+func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+/// Функция Ready параллельно проверяет все зависимости и возвращает 503, если хотя бы одна недоступна \\\
+
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	report := Report{Status: statusOK, Checks: make(map[string]CheckResult, len(h.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			result := CheckResult{Status: statusOK, DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = statusError
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = statusUnavailable
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	if report.Status != statusOK {
+		h.log.FromContext(r.Context()).WithField("checks", report.Checks).Warn("HANDLER: SERVICE IS NOT READY")
+		response.JSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+	response.JSON(w, http.StatusOK, report)
+}
+
+/// Функция DirWritable возвращает проверку того, что каталог dir существует и в него можно записать файл \\\
+
+func DirWritable(dir string) Check {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return fmt.Errorf("directory %q is not writable: %v", dir, err)
+		}
+		name := f.Name()
+		f.Close()
+		return os.Remove(name)
+	}
+}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func serve(checks map[string]Check, target string) (*httptest.ResponseRecorder, Report) {
+	router := httprouter.New()
+	NewHandler(logger.GetLogger(), checks, time.Second).Register(router)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var report Report
+	json.Unmarshal(rec.Body.Bytes(), &report)
+	return rec, report
+}
+
+func TestHealth(t *testing.T) {
+	failing := map[string]Check{"postgres": func(ctx context.Context) error { return errors.New("down") }}
+	if rec, _ := serve(failing, "/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("GET /healthz: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestReady(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	rec, report := serve(map[string]Check{"postgres": ok, "blob": DirWritable(t.TempDir())}, "/readyz")
+	if rec.Code != http.StatusOK || report.Status != statusOK {
+		t.Fatalf("GET /readyz: got status %d: %s", rec.Code, rec.Body)
+	}
+	if len(report.Checks) != 2 || report.Checks["blob"].Status != statusOK {
+		t.Errorf("GET /readyz: got checks %+v", report.Checks)
+	}
+
+	rec, report = serve(map[string]Check{
+		"postgres": ok,
+		"mail":     func(ctx context.Context) error { return errors.New("smtp server is not reachable") },
+		"blob":     DirWritable(filepath.Join(t.TempDir(), "missing")),
+	}, "/readyz")
+	if rec.Code != http.StatusServiceUnavailable || report.Status != statusUnavailable {
+		t.Fatalf("GET /readyz with failing checks: got status %d: %s", rec.Code, rec.Body)
+	}
+	if report.Checks["postgres"].Status != statusOK {
+		t.Errorf("postgres: got %+v", report.Checks["postgres"])
+	}
+	for _, name := range []string{"mail", "blob"} {
+		if report.Checks[name].Status != statusError || report.Checks[name].Error == "" {
+			t.Errorf("%s: got %+v", name, report.Checks[name])
+		}
+	}
+}
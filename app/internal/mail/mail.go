@@ -1,24 +1,63 @@
 package mail
 
 import (
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"Interior_Visualization_Shop/app/pkg/tracing"
+	"context"
 	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"mime"
+	"net"
 	"net/smtp"
 )
 
-/// Функция SendEmail создает письмо для подтвержения регистрации \\\
+/// Адрес почтового сервиса \\\
 
-func SendEmail(from, password, addressee, name, surname, confirmCode string) error {
+const (
+	smtpHost = "smtp.gmail.com"
+	smtpAddr = smtpHost + ":587"
+)
+
+/// Интерфейс Sender реализизирующий отправку писем пользователям \\\
+
+type Sender interface {
+	SendEmail(ctx context.Context, addressee, name, surname, confirmCode, confirmURL string) error
+	SendAppealEmail(ctx context.Context, addressee, fio, mailsubject string) error
+	Ping(ctx context.Context) error
+}
+
+/// Структура smtpSender реализизирующая интерфейс Sender через почтовый сервис gmail \\\
+
+type smtpSender struct {
+	from     string
+	password string
+}
+
+/// Структура NewSender возвращает новый экземпляр Sender с данными почты отправителя \\\
+
+func NewSender(from, password string) Sender {
+	return &smtpSender{
+		from:     from,
+		password: password,
+	}
+}
+
+/// Функция SendEmail создает письмо для подтвержения регистрации, confirmURL - необязательная ссылка для подтверждения в один клик \\\
+
+func (s *smtpSender) SendEmail(ctx context.Context, addressee, name, surname, confirmCode, confirmURL string) error {
 	/// Создание тела сообщения \\\
 	subject := "Confirmation of registration"
-	body := fmt.Sprintf("Hello, %s %s. To register successfully, you need to confirm your mail.\n\nYour confirmation code is: %s\n\nBest regards,Your App", name, surname, confirmCode)
-	msg := "From: " + from + "\n" +
+	body := fmt.Sprintf("Hello, %s %s. To register successfully, you need to confirm your mail.\n\nYour confirmation code is: %s", name, surname, confirmCode)
+	if confirmURL != "" {
+		body += fmt.Sprintf("\n\nOr just follow the link: %s", confirmURL)
+	}
+	body += "\n\nBest regards,Your App"
+	msg := "From: " + s.from + "\n" +
 		"To: " + addressee + "\n" +
-		"Subject: " + subject + "\n\n" +
+		header("Subject", subject) + "\n" +
 		body
-	/// Выбор почтового сервиса \\\
-	err := smtp.SendMail("smtp.gmail.com:587",
-		smtp.PlainAuth("", from, password, "smtp.gmail.com"),
-		from, []string{addressee}, []byte(msg))
+	/// Отправка письма через почтовый сервис \\\
+	err := s.send(ctx, metrics.MailConfirmation, addressee, msg)
 	if err != nil {
 		return err
 	}
@@ -27,20 +66,53 @@ func SendEmail(from, password, addressee, name, surname, confirmCode string) err
 
 /// Функция SendAppealEmail создает письмо уведомления для обратной связи \\\
 
-func SendAppealEmail(from, password, addressee, fio, mailsubject string) error {
+func (s *smtpSender) SendAppealEmail(ctx context.Context, addressee, fio, mailsubject string) error {
 	/// Создание тела сообщения \\\
 	body := fmt.Sprintf("Hello, %s. Thank you for contacting us. Your letter on the subject: '%s' has been received. It will be reviewed during the day. If you have not received an answer, then contact any messenger convenient for you in the 'Contacts' section.\n\nBest regards,Your App", fio, mailsubject)
-	msg := "From: " + from + "\n" +
+	msg := "From: " + s.from + "\n" +
 		"To: " + addressee + "\n" +
-		"Subject: " + mailsubject + "\n\n" +
+		header("Subject", mailsubject) + "\n" +
 		body
-	/// Выбор почтового сервиса \\\
-	err := smtp.SendMail("smtp.gmail.com:587",
-		smtp.PlainAuth("", from, password, "smtp.gmail.com"),
-		from, []string{addressee}, []byte(msg))
+	/// Отправка письма через почтовый сервис \\\
+	err := s.send(ctx, metrics.MailAppeal, addressee, msg)
 	if err != nil {
 		return err
 	}
-	fmt.Print(msg)
 	return nil
 }
+
+/// Функция header собирает строку заголовка письма. Значение кодируется по RFC 2047, \\\
+/// поэтому перевод строки из данных пользователя не может начать новый заголовок \\\
+
+func header(name, value string) string {
+	return name + ": " + mime.QEncoding.Encode("utf-8", value) + "\n"
+}
+
+/// Функция send отправляет письмо вида kind через SMTP, учитывая его в метриках и трассировке \\\
+
+func (s *smtpSender) send(ctx context.Context, kind, addressee, msg string) error {
+	_, span := tracing.Start(ctx, "smtp.send",
+		attribute.String("mail.kind", kind),
+		attribute.String("server.address", smtpHost),
+	)
+	defer span.End()
+
+	/// Выбор почтового сервиса \\\
+	err := smtp.SendMail(smtpAddr,
+		smtp.PlainAuth("", s.from, s.password, smtpHost),
+		s.from, []string{addressee}, []byte(msg))
+	metrics.ObserveMail(kind, err)
+	tracing.RecordError(span, err)
+	return err
+}
+
+/// Функция Ping проверяет, что почтовый сервис принимает соединения \\\
+
+func (s *smtpSender) Ping(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", smtpAddr)
+	if err != nil {
+		return fmt.Errorf("smtp server is not reachable: %v", err)
+	}
+	return conn.Close()
+}
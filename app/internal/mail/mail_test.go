@@ -0,0 +1,16 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeaderEncodesLineBreaks(t *testing.T) {
+	got := header("Subject", "Hi\r\nBcc: victim@mail.ru")
+	if strings.Count(got, "\n") != 1 || !strings.HasSuffix(got, "\n") || strings.Contains(got, "\r") {
+		t.Errorf("header = %q, want one line", got)
+	}
+	if got = header("Subject", "Kitchen design"); got != "Subject: Kitchen design\n" {
+		t.Errorf("header = %q, want the plain value", got)
+	}
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/internal/response"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/token"
+	"context"
+	"crypto/subtle"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+/// Роли пользователей \\\
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+/// Структура Principal - пользователь, от имени которого выполняется запрос \\\
+
+type Principal struct {
+	UserID    int64
+	Email     string `log:"email"`
+	Roles     []string
+	SessionID string
+}
+
+/// Функция HasRole сообщает, есть ли у пользователя роль role \\\
+
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+/// Методы String и GoString скрывают адрес почты при выводе пользователя в лог \\\
+
+func (p Principal) String() string   { return logger.Redacted(p) }
+func (p Principal) GoString() string { return logger.Redacted(p) }
+
+type principalKey struct{}
+
+/// Функция NewContext возвращает копию ctx с пользователем p \\\
+
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+/// Функция FromContext возвращает пользователя запроса. ok равен false, если запрос не аутентифицирован \\\
+
+func FromContext(ctx context.Context) (p *Principal, ok bool) {
+	p, ok = ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+/// Структура Options - где Authenticator ищет токен доступа в браузере и CSRF токен к нему. \\\
+/// Пустой AccessCookie отключает cookie: тогда принимается только заголовок Authorization \\\
+
+type Options struct {
+	AccessCookie string
+	CSRFCookie   string
+	CSRFHeader   string
+}
+
+/// Структура Authenticator проверяет токен доступа из заголовка Authorization или HttpOnly cookie \\\
+/// и кладет пользователя в контекст запроса. Заголовок важнее cookie: так API клиенты работают и из браузера \\\
+
+type Authenticator struct {
+	log      logger.Logger
+	verifier token.Verifier
+	opts     Options
+}
+
+/// Функция New возвращает Authenticator \\\
+
+func New(log logger.Logger, verifier token.Verifier, opts Options) *Authenticator {
+	return &Authenticator{
+		log:      log,
+		verifier: verifier,
+		opts:     opts,
+	}
+}
+
+/// Функция Required пропускает к next только запросы с действующим токеном доступа, остальные получают 401 \\\
+
+func (a *Authenticator) Required(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, fromCookie, err := a.authenticate(r)
+		if err != nil {
+			a.log.FromContext(r.Context()).Warnf("AUTH: %v", err)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			response.Error(w, r, apperror.Wrap(err, apperror.KindUnauthorized, apperror.ErrUnauthorized.Message))
+			return
+		}
+		if p == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			response.Error(w, r, apperror.ErrUnauthorized)
+			return
+		}
+
+		/// Cookie браузер прикладывает и к запросам с чужих сайтов, поэтому запрос, меняющий данные, \\\
+		/// должен повторить значение CSRF cookie в заголовке: прочитать его может только скрипт нашего сайта \\\
+		if fromCookie && !safeMethod(r.Method) && !a.validCSRF(r) {
+			a.log.FromContext(r.Context()).Warnf("AUTH: CSRF check failed for user %d", p.UserID)
+			response.Error(w, r, apperror.ErrInvalidCSRFToken)
+			return
+		}
+
+		/// В логах запроса появляется идентификатор пользователя, но не его почта \\\
+		ctx := NewContext(r.Context(), p)
+		log := a.log.FromContext(ctx)
+		ctx = logger.ContextWithLogger(ctx, logger.Logger{Entry: log.WithFields(logrus.Fields{"user_id": p.UserID})})
+		next(w, r.WithContext(ctx))
+	}
+}
+
+/// Функция Role пропускает к next только пользователей с одной из ролей roles, остальные получают 403 \\\
+
+func (a *Authenticator) Role(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return a.Required(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := FromContext(r.Context())
+		for _, role := range roles {
+			if p.HasRole(role) {
+				next(w, r)
+				return
+			}
+		}
+		response.Error(w, r, apperror.ErrForbidden)
+	})
+}
+
+/// Функция authenticate возвращает пользователя по токену запроса, nil - если токена нет. \\\
+/// fromCookie сообщает, что токен пришел из cookie, а не из заголовка \\\
+
+func (a *Authenticator) authenticate(r *http.Request) (p *Principal, fromCookie bool, err error) {
+	raw, fromCookie, err := a.tokenFrom(r)
+	if err != nil || raw == "" {
+		return nil, false, err
+	}
+	claims, err := a.verifier.Verify(raw)
+	if err != nil {
+		return nil, false, err
+	}
+	return &Principal{
+		UserID:    claims.User.ID,
+		Email:     claims.User.Email,
+		Roles:     claims.User.Roles,
+		SessionID: claims.SessionID,
+	}, fromCookie, nil
+}
+
+/// Функция tokenFrom достает токен из заголовка "Authorization: Bearer", а без заголовка - из cookie \\\
+
+func (a *Authenticator) tokenFrom(r *http.Request) (raw string, fromCookie bool, err error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, raw, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(raw) == "" {
+			return "", false, apperror.New(apperror.KindUnauthorized, "invalid auth header")
+		}
+		return strings.TrimSpace(raw), false, nil
+	}
+	if a.opts.AccessCookie == "" {
+		return "", false, nil
+	}
+	if c, err := r.Cookie(a.opts.AccessCookie); err == nil && c.Value != "" {
+		return c.Value, true, nil
+	}
+	return "", false, nil
+}
+
+/// Функция validCSRF проверяет CSRF токен запроса по cookie и заголовку из настроек \\\
+
+func (a *Authenticator) validCSRF(r *http.Request) bool {
+	return ValidCSRF(r, a.opts.CSRFCookie, a.opts.CSRFHeader)
+}
+
+/// Функция ValidCSRF сравнивает CSRF токен из заголовка header со значением cookie за постоянное время \\\
+
+func ValidCSRF(r *http.Request, cookie, header string) bool {
+	if cookie == "" || header == "" {
+		return false
+	}
+	c, err := r.Cookie(cookie)
+	if err != nil || c.Value == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(header)), []byte(c.Value)) == 1
+}
+
+/// Функция safeMethod сообщает, что метод не меняет данные и не требует CSRF токена \\\
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
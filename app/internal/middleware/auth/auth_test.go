@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"Interior_Visualization_Shop/app/internal/apptest"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/token"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestAuthenticator(t *testing.T) (*Authenticator, *token.JWT) {
+	t.Helper()
+	tokens := apptest.AccessTokens()
+	return New(logger.GetLogger(), tokens, Options{AccessCookie: "access_token", CSRFCookie: "csrf_token", CSRFHeader: "X-CSRF-Token"}), tokens
+}
+
+func mustIssue(t *testing.T, tokens *token.JWT, roles ...string) string {
+	t.Helper()
+	return apptest.Token(t, tokens, token.User{ID: 7, Email: "petrovmaksim1992@mail.ru", Roles: roles})
+}
+
+/// Функция whoami отвечает пользователем, которого middleware положил в контекст запроса \\\
+
+func whoami(w http.ResponseWriter, r *http.Request) {
+	p, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "no principal", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, "%d %s %s", p.UserID, p.SessionID, strings.Join(p.Roles, ","))
+}
+
+func TestRequired(t *testing.T) {
+	authn, tokens := newTestAuthenticator(t)
+	valid := mustIssue(t, tokens, RoleUser)
+	handler := authn.Required(whoami)
+
+	for name, tc := range map[string]struct {
+		header, cookie string
+		code           int
+		challenge      string
+	}{
+		"no token":                {code: http.StatusUnauthorized, challenge: "Bearer"},
+		"bearer header":           {header: "Bearer " + valid, code: http.StatusOK},
+		"lowercase scheme":        {header: "bearer " + valid, code: http.StatusOK},
+		"cookie":                  {cookie: valid, code: http.StatusOK},
+		"header wins over cookie": {header: "Bearer " + valid, cookie: "garbage", code: http.StatusOK},
+		"invalid token":           {header: "Bearer not-a-token", code: http.StatusUnauthorized, challenge: `Bearer error="invalid_token"`},
+		"invalid cookie":          {cookie: "not-a-token", code: http.StatusUnauthorized, challenge: `Bearer error="invalid_token"`},
+		"other scheme":            {header: "Basic dXNlcjpwYXNz", code: http.StatusUnauthorized, challenge: `Bearer error="invalid_token"`},
+		"empty bearer":            {header: "Bearer ", code: http.StatusUnauthorized, challenge: `Bearer error="invalid_token"`},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		if tc.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: tc.cookie})
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != tc.code {
+			t.Errorf("%s: got status %d, want %d: %s", name, rec.Code, tc.code, rec.Body)
+			continue
+		}
+		if got := rec.Header().Get("WWW-Authenticate"); got != tc.challenge {
+			t.Errorf("%s: WWW-Authenticate %q, want %q", name, got, tc.challenge)
+		}
+		if tc.code == http.StatusOK && rec.Body.String() != "7 "+apptest.SessionID+" user" {
+			t.Errorf("%s: principal %q", name, rec.Body)
+		}
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	authn, tokens := newTestAuthenticator(t)
+	valid := mustIssue(t, tokens, RoleUser)
+	handler := authn.Required(whoami)
+
+	for name, tc := range map[string]struct {
+		method, authorization, csrfCookie, csrfHeader string
+		code                                          int
+	}{
+		"cookie GET needs no CSRF token":    {method: http.MethodGet, code: http.StatusOK},
+		"cookie POST without a CSRF token":  {method: http.MethodPost, code: http.StatusForbidden},
+		"cookie POST without a CSRF cookie": {method: http.MethodPost, csrfHeader: "abc", code: http.StatusForbidden},
+		"cookie POST with another token":    {method: http.MethodPost, csrfCookie: "abc", csrfHeader: "abd", code: http.StatusForbidden},
+		"cookie POST with empty tokens":     {method: http.MethodPost, csrfCookie: "", csrfHeader: "", code: http.StatusForbidden},
+		"cookie POST with the CSRF token":   {method: http.MethodPost, csrfCookie: "abc", csrfHeader: "abc", code: http.StatusOK},
+		"cookie DELETE with the CSRF token": {method: http.MethodDelete, csrfCookie: "abc", csrfHeader: "abc", code: http.StatusOK},
+		"bearer POST needs no CSRF token":   {method: http.MethodPost, authorization: "Bearer " + valid, code: http.StatusOK},
+	} {
+		req := httptest.NewRequest(tc.method, "/protected", nil)
+		req.AddCookie(&http.Cookie{Name: "access_token", Value: valid})
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		if tc.csrfCookie != "" {
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tc.csrfCookie})
+		}
+		if tc.csrfHeader != "" {
+			req.Header.Set("X-CSRF-Token", tc.csrfHeader)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != tc.code {
+			t.Errorf("%s: got status %d, want %d: %s", name, rec.Code, tc.code, rec.Body)
+		}
+	}
+}
+
+func TestCookieDisabled(t *testing.T) {
+	_, tokens := newTestAuthenticator(t)
+	authn := New(logger.GetLogger(), tokens, Options{})
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: mustIssue(t, tokens, RoleUser)})
+	rec := httptest.NewRecorder()
+	authn.Required(whoami)(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("cookie accepted with cookies disabled: got status %d", rec.Code)
+	}
+}
+
+func TestRole(t *testing.T) {
+	authn, tokens := newTestAuthenticator(t)
+	handler := authn.Role(whoami, RoleAdmin)
+
+	for name, tc := range map[string]struct {
+		authorization string
+		code          int
+	}{
+		"anonymous": {code: http.StatusUnauthorized},
+		"user":      {authorization: "Bearer " + mustIssue(t, tokens, RoleUser), code: http.StatusForbidden},
+		"admin":     {authorization: "Bearer " + mustIssue(t, tokens, RoleUser, RoleAdmin), code: http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != tc.code {
+			t.Errorf("%s: got status %d, want %d", name, rec.Code, tc.code)
+		}
+	}
+}
+
+func TestPrincipalHidesEmail(t *testing.T) {
+	p := Principal{UserID: 7, Email: "petrovmaksim1992@mail.ru", Roles: []string{RoleUser}}
+	for _, s := range []string{p.String(), fmt.Sprintf("%v", p), fmt.Sprintf("%#v", &p)} {
+		if strings.Contains(s, "petrovmaksim1992") {
+			t.Errorf("email leaked: %s", s)
+		}
+	}
+	if _, ok := FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); ok {
+		t.Error("FromContext found a principal in an anonymous request")
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/internal/response"
+	"net/http"
+)
+
+/// Функция BodyLimit ограничивает размер тела запроса maxBytes байтами. \\\
+/// Запрос с заранее известной длиной больше лимита отклоняется сразу, остальные обрываются при чтении \\\
+
+func BodyLimit(maxBytes int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		if maxBytes <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				response.Error(w, r, apperror.ErrRequestTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import "net/http"
+
+/// Тип Middleware оборачивает обработчик дополнительной логикой \\\
+
+type Middleware func(http.Handler) http.Handler
+
+/// Функция Chain оборачивает h в middlewares по порядку: первый в списке выполняется первым \\\
+
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"Interior_Visualization_Shop/app/internal/response"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"compress/gzip"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}), mark("first"), mark("second"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := strings.Join(calls, ","); got != "first,second,handler" {
+		t.Errorf("calls = %s", got)
+	}
+}
+
+func TestRecoveryAnswersProblem(t *testing.T) {
+	h := Recovery(logger.GetLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != response.ProblemContentType {
+		t.Errorf("content type = %q", ct)
+	}
+	if strings.Contains(rec.Body.String(), "boom") {
+		t.Errorf("panic value leaked to the client: %s", rec.Body)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	h := BodyLimit(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			response.Error(w, r, err)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("0123456789")))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("01234")))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("interior ", 100)
+	h := Compress()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/encoded" {
+			w.Header().Set("Content-Encoding", "br")
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("response is not compressed: %v", rec.Header())
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(gz); string(got) != body {
+		t.Errorf("decompressed body differs")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/encoded", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "br" || rec.Body.String() != body {
+		t.Errorf("already encoded response was changed")
+	}
+}
+
+func TestCompressSkipsRanges(t *testing.T) {
+	body := strings.Repeat("interior ", 100)
+	h := Compress()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/partial" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Range", "bytes 0-8/900")
+			w.WriteHeader(http.StatusPartialContent)
+			io.WriteString(w, body[:9])
+			return
+		}
+		http.ServeContent(w, r, "page.txt", time.Time{}, strings.NewReader(body))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Range", "bytes=9-17")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPartialContent || rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != body[9:18] {
+		t.Errorf("Range request: got status %d, Content-Encoding %q and body %q", rec.Code, rec.Header().Get("Content-Encoding"), rec.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/partial", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != body[:9] {
+		t.Errorf("206 response was compressed: %v", rec.Header())
+	}
+}
+
+func TestFlushThroughWrappers(t *testing.T) {
+	router := httprouter.New()
+	var flushErr error
+	router.GET("/events", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: ready\n\n")
+		flushErr = http.NewResponseController(w).Flush()
+	})
+	h := Chain(router, Logging(logger.GetLogger()), Metrics(router), Compress())
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if flushErr != nil || !rec.Flushed {
+		t.Fatalf("Flush through the middlewares: error %v, flushed %t", flushErr, rec.Flushed)
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(gz); string(got) != "data: ready\n\n" {
+		t.Errorf("decompressed body = %q", got)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	h := SecurityHeaders("default-src 'self'")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for header, want := range map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         "DENY",
+		"Content-Security-Policy": "default-src 'self'",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCORSAllowsDelete(t *testing.T) {
+	h := CORS(CORSOptions{
+		AllowedOrigins: []string{"http://localhost:3001"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodOptions},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/users/42", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if got := preflight("http://localhost:3001").Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3001" {
+		t.Errorf("DELETE preflight from an allowed origin: Access-Control-Allow-Origin = %q", got)
+	}
+	if got := preflight("http://evil.example").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("unknown origin was allowed: %q", got)
+	}
+}
+
+func TestHSTSOnlyOverTLS(t *testing.T) {
+	h := HSTS(31536000, true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://vjm.example/", nil))
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("HSTS sent over plain HTTP: %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "https://vjm.example/", nil))
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=31536000; includeSubDomains" {
+		t.Errorf("Strict-Transport-Security = %q", got)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/rs/cors"
+	"net/http"
+)
+
+/// Настройки CORS: разрешенные источники (домены), HTTP-методы и заголовки \\\
+
+type CORSOptions struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int
+}
+
+/// Функция CORS отвечает на preflight запросы и добавляет заголовки CORS по настройкам opts. \\\
+/// Пустой список источников rs/cors считает разрешением для всех, поэтому без источников \\\
+/// заголовки CORS не добавляются и браузер разрешает запросы только с того же источника \\\
+
+func CORS(opts CORSOptions) Middleware {
+	if len(opts.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+	c := cors.New(cors.Options{
+		AllowedOrigins:   opts.AllowedOrigins,
+		AllowedMethods:   opts.AllowedMethods,
+		AllowedHeaders:   opts.AllowedHeaders,
+		ExposedHeaders:   opts.ExposedHeaders,
+		AllowCredentials: opts.AllowCredentials,
+		MaxAge:           opts.MaxAge,
+	})
+	return func(next http.Handler) http.Handler {
+		return c.Handler(next)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		want    string
+	}{
+		{"no origins allows none", nil, "https://evil.example", ""},
+		{"foreign origin", []string{"https://shop.example"}, "https://evil.example", ""},
+		{"allowed origin", []string{"https://shop.example"}, "https://shop.example", "https://shop.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := CORS(CORSOptions{
+				AllowedOrigins:   tt.origins,
+				AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+				AllowCredentials: true,
+			})(ok)
+			for _, method := range []string{http.MethodGet, http.MethodOptions} {
+				req := httptest.NewRequest(method, "/users", nil)
+				req.Header.Set("Origin", tt.origin)
+				if method == http.MethodOptions {
+					req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+					t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", method, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+/// Функция Logging пишет в лог строку на каждый запрос: метод, путь, код ответа, размер и длительность \\\
+
+func Logging(log logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			entry := log.FromContext(r.Context()).WithFields(logrus.Fields{
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"status":      rec.status,
+				"bytes":       rec.bytes,
+				"duration_ms": time.Since(start).Milliseconds(),
+			})
+			switch {
+			case rec.status >= http.StatusInternalServerError:
+				entry.Error("HTTP REQUEST")
+			case rec.status >= http.StatusBadRequest:
+				entry.Warn("HTTP REQUEST")
+			default:
+				entry.Info("HTTP REQUEST")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"context"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/// Метка маршрута для запросов, не совпавших ни с одним маршрутом, чтобы число меток оставалось ограниченным \\\
+
+const unmatchedRoute = "unmatched"
+
+/// Структура statusRecorder запоминает код ответа и число байт тела, записанные обработчиком \\\
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+/// Функция Flush передает накопленный ответ клиенту, если это умеет исходный ResponseWriter \\\
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+/// Функция Unwrap открывает исходный ResponseWriter для http.ResponseController \\\
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+/// Функция Metrics измеряет длительность и коды ответов HTTP запросов по шаблонам маршрутов router \\\
+
+func Metrics(router *httprouter.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			route, r := resolveRoute(router, r)
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			metrics.ObserveHTTP(r.Method, route, rec.status, time.Since(start))
+		})
+	}
+}
+
+/// Ключ контекста, под которым хранится шаблон маршрута запроса \\\
+
+type routeKey struct{}
+
+/// Функция resolveRoute возвращает шаблон маршрута запроса r и запрос, в контексте которого он сохранен. \\\
+/// Шаблон вычисляется один раз на запрос, и следующие middleware берут его из контекста \\\
+
+func resolveRoute(router *httprouter.Router, r *http.Request) (string, *http.Request) {
+	if route, ok := r.Context().Value(routeKey{}).(string); ok {
+		return route, r
+	}
+	route := routePattern(router, r)
+	return route, r.WithContext(context.WithValue(r.Context(), routeKey{}, route))
+}
+
+/// Функция routePattern восстанавливает шаблон маршрута (/users/profile/:id) по пути запроса. \\\
+/// Значение параметра может совпасть с соседним сегментом пути (/users/users), поэтому сегмент \\\
+/// считается параметром, только если router подставляет в параметр пробное значение на его месте \\\
+
+func routePattern(router *httprouter.Router, r *http.Request) string {
+	handle, params, _ := router.Lookup(r.Method, r.URL.Path)
+	if handle == nil {
+		/// Маршруты, зарегистрированные через HandlerFunc, Lookup тоже находит, nil - значит маршрута нет \\\
+		return unmatchedRoute
+	}
+	segments := strings.Split(r.URL.Path, "/")
+	route := append([]string(nil), segments...)
+	next := 1
+	for _, p := range params {
+		/// Параметр *name забирает весь остаток пути вместе с ведущим "/" \\\
+		if strings.HasPrefix(p.Value, "/") {
+			rest := strings.Count(p.Value, "/")
+			return strings.Join(route[:len(route)-rest], "/") + "/*" + p.Key
+		}
+		for i := next; i < len(segments); i++ {
+			if segments[i] == p.Value && isParam(router, r.Method, segments, i, p.Key) {
+				route[i] = ":" + p.Key
+				next = i + 1
+				break
+			}
+		}
+	}
+	return strings.Join(route, "/")
+}
+
+/// Пробное значение параметра: в пути запроса такого сегмента быть не может \\\
+
+const probeSegment = "\x00probe"
+
+/// Функция isParam проверяет, что сегмент i пути segments попадает в параметр key \\\
+
+func isParam(router *httprouter.Router, method string, segments []string, i int, key string) bool {
+	probe := append([]string(nil), segments...)
+	probe[i] = probeSegment
+	handle, params, _ := router.Lookup(method, strings.Join(probe, "/"))
+	return handle != nil && params.ByName(key) == probeSegment
+}
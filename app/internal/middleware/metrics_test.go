@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutePattern(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request, httprouter.Params) {}
+	router := httprouter.New()
+	router.GET("/users/:id", noop)
+	router.GET("/catalog/:section/items/:item", noop)
+	router.GET("/static/*filepath", noop)
+	router.Handler(http.MethodGet, "/healthz", http.NotFoundHandler())
+
+	for path, want := range map[string]string{
+		"/users/1":                       "/users/:id",
+		"/users/users":                   "/users/:id",
+		"/catalog/items/items/items":     "/catalog/:section/items/:item",
+		"/catalog/renders/items/renders": "/catalog/:section/items/:item",
+		"/static/css/style.css":          "/static/*filepath",
+		"/healthz":                       "/healthz",
+		"/nowhere/at/all":                unmatchedRoute,
+		"/users/1/and/then/some":         unmatchedRoute,
+	} {
+		if got := routePattern(router, httptest.NewRequest(http.MethodGet, path, nil)); got != want {
+			t.Errorf("routePattern(%s) = %s, want %s", path, got, want)
+		}
+	}
+	if got := routePattern(router, httptest.NewRequest(http.MethodPost, "/users/1", nil)); got != unmatchedRoute {
+		t.Errorf("routePattern for an unregistered method = %s, want %s", got, unmatchedRoute)
+	}
+}
+
+func TestRouteResolvedOnce(t *testing.T) {
+	router := httprouter.New()
+	var route interface{}
+	router.GET("/users/:id", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		route = r.Context().Value(routeKey{})
+	})
+	Chain(router, Tracing(router), Metrics(router)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	if route != "/users/:id" {
+		t.Errorf("route in the context = %v, want /users/:id", route)
+	}
+
+	/// Сохраненный шаблон берется из контекста без обращения к router \\\
+	_, r := resolveRoute(router, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	if got, _ := resolveRoute(nil, r); got != "/users/:id" {
+		t.Errorf("resolveRoute with a stored route = %s, want /users/:id", got)
+	}
+}
+
+func TestMetricsRecordsStatus(t *testing.T) {
+	router := httprouter.New()
+	router.GET("/users/profile/:id", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	})
+	var recorded *statusRecorder
+	spy := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorded, _ = w.(*statusRecorder)
+			next.ServeHTTP(w, r)
+		})
+	}
+	rec := httptest.NewRecorder()
+	Chain(router, Metrics(router), spy).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/profile/7", nil))
+
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusTeapot)
+	}
+	if recorded == nil || recorded.status != http.StatusTeapot || recorded.bytes != len("short and stout") {
+		t.Errorf("statusRecorder = %+v, want status %d and %d bytes", recorded, http.StatusTeapot, len("short and stout"))
+	}
+}
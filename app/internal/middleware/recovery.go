@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"Interior_Visualization_Shop/app/internal/response"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+/// Функция Recovery перехватывает панику в обработчике, пишет ее в лог со стеком и отвечает 500 \\\
+
+func Recovery(log logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				/// http.ErrAbortHandler - штатный способ прервать ответ, его не нужно перехватывать \\\
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				log.FromContext(r.Context()).WithField("stack", string(debug.Stack())).Errorf("panic: %v", rec)
+				response.Error(w, r, fmt.Errorf("panic: %v", rec))
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
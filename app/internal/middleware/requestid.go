@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+/// Заголовок, в котором передается идентификатор запроса \\\
+
+const RequestIDHeader = "X-Request-ID"
+
+/// Максимальная длина идентификатора запроса, принимаемого от клиента \\\
+
+const maxRequestIDLength = 64
+
+type requestIDKey struct{}
+
+/// Функция RequestID присваивает запросу идентификатор (или принимает его из заголовка X-Request-ID), \\\
+/// возвращает его клиенту и привязывает к контексту запроса логгер с полем request_id \\\
+
+func RequestID(log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			/// Если запрос уже трассируется, в логи попадает и trace_id, чтобы найти по нему спаны \\\
+			fields := logrus.Fields{"request_id": id}
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				fields["trace_id"] = sc.TraceID().String()
+			}
+
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			ctx = logger.ContextWithLogger(ctx, logger.Logger{Entry: log.WithFields(fields)})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+/// Функция GetRequestID возвращает идентификатор текущего запроса или пустую строку \\\
+
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+/// Функция newRequestID генерирует случайный идентификатор запроса \\\
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+/// Функция validRequestID пропускает только короткие идентификаторы из безопасных символов, чтобы клиент не мог подделать строки лога \\\
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
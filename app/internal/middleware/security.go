@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+/// Функция SecurityHeaders добавляет к ответам заголовки, запрещающие угадывание типа, встраивание в чужие фреймы \\\
+/// и передачу полного адреса страницы. contentSecurityPolicy отправляется, только если задана \\\
+
+func SecurityHeaders(contentSecurityPolicy string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			h.Set("Cross-Origin-Opener-Policy", "same-origin")
+			if contentSecurityPolicy != "" {
+				h.Set("Content-Security-Policy", contentSecurityPolicy)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+/// Функция HSTS добавляет Strict-Transport-Security к ответам, полученным по TLS, чтобы браузер больше не ходил по HTTP. \\\
+/// По обычному HTTP заголовок не отправляется: браузеры его там игнорируют \\\
+
+func HSTS(maxAge int, includeSubdomains bool) Middleware {
+	value := "max-age=" + strconv.Itoa(maxAge)
+	if includeSubdomains {
+		value += "; includeSubDomains"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS != nil {
+				w.Header().Set("Strict-Transport-Security", value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
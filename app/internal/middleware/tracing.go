@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"Interior_Visualization_Shop/app/pkg/tracing"
+	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"net/http"
+)
+
+/// Функция Tracing открывает спан на каждый HTTP запрос с именем "МЕТОД шаблон-маршрута" \\\
+
+func Tracing(router *httprouter.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, r := resolveRoute(router, r)
+			ctx, span := tracing.StartServer(r.Context(), r.Header, r.Method+" "+route,
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			)
+			defer span.End()
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+			if rec.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rec.status))
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"Interior_Visualization_Shop/app/internal/apptest"
+	"Interior_Visualization_Shop/app/internal/middleware/auth"
+	"Interior_Visualization_Shop/app/internal/user"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/token"
+	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTracingSpansAcrossLayers(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	log := logger.GetLogger()
+	tokens := apptest.AccessTokens()
+	admin := apptest.Bearer(t, tokens, token.User{ID: 100, Roles: []string{auth.RoleAdmin}})
+
+	router := httprouter.New()
+	user.NewHandler(log, user.NewService(user.NewMemoryStorage(), log), auth.New(log, tokens, auth.Options{})).Register(router)
+	handler := Tracing(router)(RequestID(log)(router))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/users",
+		strings.NewReader(`{"email":"petrovmaksim1992@mail.ru","name":"Maksim","surname":"Petrov","password":"abcdEFG1"}`))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	req.Header.Set("Authorization", admin)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/users/profile/42", nil)
+	req.Header.Set("Authorization", admin)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	byName := make(map[string]sdktrace.ReadOnlySpan, len(spans))
+	for _, span := range spans {
+		byName[span.Name()] = span
+	}
+
+	create, service := byName["POST /users"], byName["user.Service.Create"]
+	if create == nil || service == nil {
+		t.Fatalf("missing spans, got %v", byName)
+	}
+	if create.SpanContext().TraceID().String() != traceID {
+		t.Errorf("server span did not continue the incoming trace: %s", create.SpanContext().TraceID())
+	}
+	if service.Parent().SpanID() != create.SpanContext().SpanID() {
+		t.Error("service span is not a child of the server span")
+	}
+
+	get := byName["GET /users/profile/:id"]
+	if get == nil {
+		t.Fatalf("missing span for the parameterized route, got %v", byName)
+	}
+	for _, attr := range get.Attributes() {
+		if attr.Key == "http.response.status_code" && attr.Value.AsInt64() != http.StatusNotFound {
+			t.Errorf("status code attribute: got %d", attr.Value.AsInt64())
+		}
+	}
+}
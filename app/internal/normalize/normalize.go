@@ -0,0 +1,70 @@
+package normalize
+
+import (
+	"fmt"
+	"github.com/nyaruka/phonenumbers"
+	"strings"
+	"sync"
+)
+
+/// Настройки приведения контактов к каноническому виду \\\
+/// DefaultRegion - код страны ISO 3166-1 для номеров, набранных без +, например RU \\\
+/// LowercaseEmail - приводить к нижнему регистру весь адрес, а не только домен \\\
+
+type Options struct {
+	DefaultRegion  string
+	LowercaseEmail bool
+}
+
+var (
+	mu   sync.RWMutex
+	opts = Options{DefaultRegion: "RU", LowercaseEmail: true}
+)
+
+/// Функция Configure применяет настройки opts, вызывается один раз при запуске \\\
+
+func Configure(o Options) error {
+	o.DefaultRegion = strings.ToUpper(strings.TrimSpace(o.DefaultRegion))
+	if o.DefaultRegion != "" && phonenumbers.GetCountryCodeForRegion(o.DefaultRegion) == 0 {
+		return fmt.Errorf("unknown phone region %q", o.DefaultRegion)
+	}
+	mu.Lock()
+	opts = o
+	mu.Unlock()
+	return nil
+}
+
+func current() Options {
+	mu.RLock()
+	defer mu.RUnlock()
+	return opts
+}
+
+/// Функция Email обрезает пробелы и приводит домен, а при LowercaseEmail и весь адрес, к нижнему регистру \\\
+
+func Email(email string) string {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+	if current().LowercaseEmail {
+		local = strings.ToLower(local)
+	}
+	return local + "@" + domain
+}
+
+/// Функция Phone разбирает номер в любом привычном написании и возвращает его в формате E.164: +79656879175. \\\
+/// Номера без + считаются номерами региона DefaultRegion \\\
+
+func Phone(phone string) (string, error) {
+	num, err := phonenumbers.Parse(strings.TrimSpace(phone), current().DefaultRegion)
+	if err != nil {
+		return phone, err
+	}
+	if !phonenumbers.IsValidNumber(num) {
+		return phone, fmt.Errorf("invalid phone number")
+	}
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
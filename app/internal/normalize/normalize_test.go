@@ -0,0 +1,46 @@
+package normalize
+
+import "testing"
+
+func TestEmail(t *testing.T) {
+	tests := []struct {
+		lowercase bool
+		in, want  string
+	}{
+		{true, " Foo.Bar@Mail.RU ", "foo.bar@mail.ru"},
+		{false, " Foo.Bar@Mail.RU ", "Foo.Bar@mail.ru"},
+		{true, "not-an-email", "not-an-email"},
+	}
+	for _, tc := range tests {
+		if err := Configure(Options{DefaultRegion: "RU", LowercaseEmail: tc.lowercase}); err != nil {
+			t.Fatalf("Configure: %v", err)
+		}
+		if got := Email(tc.in); got != tc.want {
+			t.Errorf("Email(%q) with lowercase=%v: got %q, want %q", tc.in, tc.lowercase, got, tc.want)
+		}
+	}
+}
+
+func TestPhone(t *testing.T) {
+	if err := Configure(Options{DefaultRegion: "ru", LowercaseEmail: true}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	for _, in := range []string{"89656879175", "8 (965) 687-91-75", "+7 965 687 91 75", " +79656879175 "} {
+		got, err := Phone(in)
+		if err != nil || got != "+79656879175" {
+			t.Errorf("Phone(%q): got %q, %v, want +79656879175", in, got, err)
+		}
+	}
+	if got, err := Phone("+1 202-555-0143"); err != nil || got != "+12025550143" {
+		t.Errorf("Phone with a foreign code: got %q, %v", got, err)
+	}
+	for _, in := range []string{"", "call me", "123"} {
+		if got, err := Phone(in); err == nil {
+			t.Errorf("Phone(%q): got %q, want an error", in, got)
+		}
+	}
+
+	if err := Configure(Options{DefaultRegion: "XX"}); err == nil {
+		t.Error("Configure with an unknown region: got nil error")
+	}
+}
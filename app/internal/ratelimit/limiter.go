@@ -0,0 +1,245 @@
+package ratelimit
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/internal/response"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/// Максимальный размер тела запроса, из которого извлекается email \\\
+
+const maxPeekBody = 1 << 20
+
+/// Настройки ограничения частоты запросов и блокировки после неудачных входов \\\
+
+type Options struct {
+	Enabled        bool
+	Window         time.Duration
+	IPLimit        int
+	EmailLimit     int
+	MaxFailures    int
+	FailureWindow  time.Duration
+	LockoutBase    time.Duration
+	LockoutMax     time.Duration
+	TrustForwarded bool
+}
+
+/// Структура Limiter ограничивает частоту запросов по IP и email и блокирует вход после серии неудач \\\
+
+type Limiter struct {
+	log     logger.Logger
+	storage Storage
+	opts    Options
+	now     func() time.Time
+}
+
+/// Структура NewLimiter возвращает новый экземпляр Limiter инициализируя переданные в него аргументы \\\
+
+func NewLimiter(log logger.Logger, storage Storage, opts Options) *Limiter {
+	return &Limiter{
+		log:     log,
+		storage: storage,
+		opts:    opts,
+		now:     time.Now,
+	}
+}
+
+/// Функция Attempts ограничивает число запросов к маршруту scope с одного IP и для одного email из тела запроса \\\
+
+func (l *Limiter) Attempts(scope string, next http.HandlerFunc) http.HandlerFunc {
+	if !l.opts.Enabled {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !l.allow(w, r, scope, readEmail(r)) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+/// Функция Login ограничивает попытки входа как Attempts и прогрессивно блокирует email после MaxFailures неудач подряд. \\\
+/// Неудачей считается только ответ 401 - неверный пароль. Ошибки формы и CSRF может прислать кто угодно, \\\
+/// и если бы они считались, любой мог бы заблокировать вход чужому email. Успешный вход сбрасывает счетчик \\\
+
+func (l *Limiter) Login(scope string, next http.HandlerFunc) http.HandlerFunc {
+	if !l.opts.Enabled {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := l.log.FromContext(r.Context())
+		email := readEmail(r)
+		if !l.allow(w, r, scope, email) {
+			return
+		}
+		if email == "" {
+			next(w, r)
+			return
+		}
+
+		lockKey := "lock:" + scope + ":" + email
+		until, err := l.storage.LockedUntil(r.Context(), lockKey)
+		if err != nil {
+			unavailable(w, r, l.opts.Window, fmt.Errorf("cannot check login lockout: %w", err))
+			return
+		}
+		if !until.IsZero() {
+			log.Warn("RATE LIMIT: LOGIN IS LOCKED")
+			tooManyRequests(w, r, until.Sub(l.now()))
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		failKey := "fail:" + scope + ":" + email
+		switch {
+		case rec.status < http.StatusBadRequest:
+			if err = l.storage.Reset(r.Context(), failKey); err == nil {
+				err = l.storage.Reset(r.Context(), lockKey)
+			}
+		case rec.status == http.StatusUnauthorized:
+			var failures int
+			failures, err = l.storage.Incr(r.Context(), failKey, l.opts.FailureWindow)
+			if err == nil && failures >= l.opts.MaxFailures {
+				lockout := l.lockout(failures)
+				log.WithField("lockout", lockout.String()).Warn("RATE LIMIT: LOCKING LOGIN AFTER FAILED ATTEMPTS")
+				err = l.storage.Lock(r.Context(), lockKey, l.now().Add(lockout))
+			}
+		}
+		if err != nil {
+			log.WithError(err).Error("cannot update failed login counter")
+		}
+	}
+}
+
+/// Функция lockout удваивает время блокировки с каждой неудачей сверх MaxFailures, но не больше LockoutMax \\\
+
+func (l *Limiter) lockout(failures int) time.Duration {
+	exp := failures - l.opts.MaxFailures
+	if exp > 30 {
+		exp = 30
+	}
+	lockout := time.Duration(float64(l.opts.LockoutBase) * math.Pow(2, float64(exp)))
+	if lockout > l.opts.LockoutMax {
+		lockout = l.opts.LockoutMax
+	}
+	return lockout
+}
+
+/// Структура limitKey - ключ счетчика запросов key с лимитом limit \\\
+
+type limitKey struct {
+	key   string
+	limit int
+}
+
+/// Функция allow учитывает запрос в счетчиках IP и затем email и отвечает 429, если лимит превышен. \\\
+/// При недоступности хранилища запрос отклоняется с 503: иначе сбой хранилища снимал бы защиту от перебора \\\
+
+func (l *Limiter) allow(w http.ResponseWriter, r *http.Request, scope, email string) bool {
+	log := l.log.FromContext(r.Context())
+
+	keys := []limitKey{{key: "ip:" + scope + ":" + l.clientIP(r), limit: l.opts.IPLimit}}
+	if email != "" {
+		keys = append(keys, limitKey{key: "email:" + scope + ":" + email, limit: l.opts.EmailLimit})
+	}
+	for _, k := range keys {
+		count, err := l.storage.Incr(r.Context(), k.key, l.opts.Window)
+		if err != nil {
+			unavailable(w, r, l.opts.Window, fmt.Errorf("cannot check rate limit: %w", err))
+			return false
+		}
+		if count > k.limit {
+			log.WithField("scope", scope).Warn("RATE LIMIT: TOO MANY REQUESTS")
+			tooManyRequests(w, r, l.opts.Window)
+			return false
+		}
+	}
+	return true
+}
+
+/// Функция clientIP возвращает IP клиента. X-Forwarded-For учитывается, только если сервер стоит за доверенным прокси \\\
+
+func (l *Limiter) clientIP(r *http.Request) string {
+	if l.opts.TrustForwarded {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+/// Функция readEmail достает поле email из JSON тела запроса и возвращает тело на место для обработчика \\\
+
+func readEmail(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBody))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+
+	var input struct {
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(body, &input) != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(input.Email))
+}
+
+/// Функция tooManyRequests отвечает 429 с заголовком Retry-After \\\
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	setRetryAfter(w, retryAfter)
+	response.Error(w, r, apperror.ErrTooManyRequests)
+}
+
+/// Функция unavailable отвечает 503 с заголовком Retry-After, когда хранилище лимитов недоступно \\\
+
+func unavailable(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, err error) {
+	setRetryAfter(w, retryAfter)
+	response.Error(w, r, apperror.Wrap(err, apperror.KindUnavailable, apperror.ErrUnavailable.Message))
+}
+
+/// Функция setRetryAfter задает заголовок Retry-After в целых секундах, не меньше одной \\\
+
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+/// Структура statusRecorder запоминает код ответа обработчика входа \\\
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
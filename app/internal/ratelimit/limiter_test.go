@@ -0,0 +1,233 @@
+package ratelimit
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+/// Функция newTestLimiter возвращает ограничитель, хранилище и блокировки которого идут по общим часам *now \\\
+
+func newTestLimiter(opts Options, now *time.Time) *Limiter {
+	storage := NewMemoryStorage().(*MemoryStorage)
+	storage.now = func() time.Time { return *now }
+	l := NewLimiter(logger.GetLogger(), storage, opts)
+	l.now = storage.now
+	return l
+}
+
+func post(h http.HandlerFunc, ip, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/sign_in/mail", strings.NewReader(body))
+	req.RemoteAddr = ip + ":40000"
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func TestAttemptsLimitsByIPAndEmail(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(Options{Enabled: true, Window: time.Minute, IPLimit: 3, EmailLimit: 2}, &now)
+	h := l.Attempts("sign_up", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	for i := 0; i < 2; i++ {
+		if rec := post(h, "10.0.0.1", `{"email":"Petrov@mail.ru"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("request #%d: got status %d", i+1, rec.Code)
+		}
+	}
+	rec := post(h, "10.0.0.2", `{"email":" petrov@MAIL.ru "}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request for the email: got status %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("Retry-After: got %q, want 60", rec.Header().Get("Retry-After"))
+	}
+
+	if rec = post(h, "10.0.0.1", `{"email":"other@mail.ru"}`); rec.Code != http.StatusCreated {
+		t.Errorf("third request from the IP: got status %d", rec.Code)
+	}
+	if rec = post(h, "10.0.0.1", `{"email":"third@mail.ru"}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("fourth request from the IP: got status %d, want 429", rec.Code)
+	}
+
+	now = now.Add(time.Minute)
+	if rec = post(h, "10.0.0.1", `{"email":"petrov@mail.ru"}`); rec.Code != http.StatusCreated {
+		t.Errorf("request after the window: got status %d", rec.Code)
+	}
+}
+
+func TestAttemptsKeepsBodyForHandler(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(Options{Enabled: true, Window: time.Minute, IPLimit: 10, EmailLimit: 10}, &now)
+
+	const body = `{"email":"petrov@mail.ru","password":"secret"}`
+	var got string
+	h := l.Attempts("sign_up", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+	})
+	post(h, "10.0.0.1", body)
+	if got != body {
+		t.Errorf("handler body: got %q, want %q", got, body)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(Options{
+		Enabled:       true,
+		Window:        time.Minute,
+		IPLimit:       1000,
+		EmailLimit:    1000,
+		MaxFailures:   3,
+		FailureWindow: 24 * time.Hour,
+		LockoutBase:   time.Minute,
+		LockoutMax:    3 * time.Minute,
+	}, &now)
+	h := l.Login("sign_in", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(b), "right") {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	const wrong = `{"email":"petrov@mail.ru","password":"wrong"}`
+	const right = `{"email":"petrov@mail.ru","password":"right"}`
+
+	for i := 0; i < 3; i++ {
+		if rec := post(h, "10.0.0.1", wrong); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("failure #%d: got status %d", i+1, rec.Code)
+		}
+	}
+
+	/// Третья ошибка блокирует адрес на LockoutBase, даже для верного пароля \\\
+	rec := post(h, "10.0.0.1", right)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("locked login: got status %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	/// Каждая следующая ошибка удваивает блокировку, но не дольше LockoutMax \\\
+	for _, want := range []string{"120", "180"} {
+		now = now.Add(3 * time.Minute)
+		post(h, "10.0.0.1", wrong)
+		if rec = post(h, "10.0.0.1", right); rec.Header().Get("Retry-After") != want {
+			t.Errorf("lockout: got Retry-After %q, want %s", rec.Header().Get("Retry-After"), want)
+		}
+	}
+
+	/// Успешный вход сбрасывает ошибки \\\
+	now = now.Add(3 * time.Minute)
+	if rec = post(h, "10.0.0.1", right); rec.Code != http.StatusOK {
+		t.Fatalf("login after lockout: got status %d", rec.Code)
+	}
+	if rec = post(h, "10.0.0.1", wrong); rec.Code != http.StatusUnauthorized {
+		t.Errorf("failure after reset: got status %d, want 401", rec.Code)
+	}
+}
+
+func TestLoginCountsOnlyWrongPasswords(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(Options{
+		Enabled:       true,
+		Window:        time.Minute,
+		IPLimit:       1000,
+		EmailLimit:    1000,
+		MaxFailures:   3,
+		FailureWindow: 24 * time.Hour,
+		LockoutBase:   time.Minute,
+		LockoutMax:    3 * time.Minute,
+	}, &now)
+	h := l.Login("sign_in", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		switch {
+		case strings.Contains(string(b), "malformed"):
+			w.WriteHeader(http.StatusBadRequest)
+		case strings.Contains(string(b), "csrf"):
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"email":"petrov@mail.ru","password":"malformed"}`, http.StatusBadRequest},
+		{`{"email":"petrov@mail.ru","password":"no csrf"}`, http.StatusForbidden},
+	} {
+		for i := 0; i < 5; i++ {
+			if rec := post(h, "10.0.0.1", tc.body); rec.Code != tc.want {
+				t.Fatalf("request #%d: got status %d, want %d", i+1, rec.Code, tc.want)
+			}
+		}
+	}
+	if rec := post(h, "10.0.0.1", `{"email":"petrov@mail.ru","password":"right"}`); rec.Code != http.StatusOK {
+		t.Errorf("login after rejected forms: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestDisabledLimiterPassesThrough(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(Options{Enabled: false, IPLimit: 0}, &now)
+	h := l.Login("sign_in", func(w http.ResponseWriter, r *http.Request) {})
+	if rec := post(h, "10.0.0.1", `{"email":"petrov@mail.ru"}`); rec.Code != http.StatusOK {
+		t.Errorf("disabled limiter: got status %d", rec.Code)
+	}
+}
+
+/// Структура recordingStorage запоминает счетчики в порядке, в котором их проверяет ограничитель, \\\
+/// и отвечает на каждый вызов ошибкой err, как недоступная база или база без свободных соединений \\\
+
+type recordingStorage struct {
+	Storage
+	keys []string
+	err  error
+}
+
+func (s *recordingStorage) Incr(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.keys = append(s.keys, key)
+	if s.err != nil {
+		return 0, s.err
+	}
+	return 1, nil
+}
+
+func TestStorageFailureRejects(t *testing.T) {
+	storage := &recordingStorage{err: errors.New("conn busy")}
+	l := NewLimiter(logger.GetLogger(), storage, Options{Enabled: true, Window: time.Minute, IPLimit: 10, EmailLimit: 10})
+	called := false
+	next := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	for name, h := range map[string]http.HandlerFunc{"attempts": l.Attempts("sign_up", next), "login": l.Login("sign_in", next)} {
+		rec := post(h, "10.0.0.1", `{"email":"petrov@mail.ru"}`)
+		if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "60" {
+			t.Errorf("%s: got status %d, Retry-After %q, want 503 and 60", name, rec.Code, rec.Header().Get("Retry-After"))
+		}
+	}
+	if called {
+		t.Error("the handler ran although the rate limit could not be checked")
+	}
+}
+
+func TestCountersOrder(t *testing.T) {
+	storage := &recordingStorage{}
+	l := NewLimiter(logger.GetLogger(), storage, Options{Enabled: true, Window: time.Minute, IPLimit: 10, EmailLimit: 10})
+	h := l.Attempts("sign_up", func(w http.ResponseWriter, r *http.Request) {})
+	for i := 0; i < 20; i++ {
+		post(h, "10.0.0.1", `{"email":"petrov@mail.ru"}`)
+	}
+	for i := 0; i < len(storage.keys); i += 2 {
+		if storage.keys[i] != "ip:sign_up:10.0.0.1" || storage.keys[i+1] != "email:sign_up:petrov@mail.ru" {
+			t.Fatalf("counters checked in order %v, want the IP before the email", storage.keys[i:i+2])
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Storage = &MemoryStorage{}
+
+/// Структура counter - счетчик попыток в окне, которое заканчивается в expiresAt \\\
+
+type counter struct {
+	count     int
+	expiresAt time.Time
+}
+
+/// Структура MemoryStorage хранящая счетчики и блокировки в памяти одного процесса \\\
+
+type MemoryStorage struct {
+	mu       sync.Mutex
+	counters map[string]counter
+	locks    map[string]time.Time
+	now      func() time.Time
+}
+
+/// Структура NewMemoryStorage возвращает новый пустой экземпляр MemoryStorage \\\
+
+func NewMemoryStorage() Storage {
+	return &MemoryStorage{
+		counters: make(map[string]counter),
+		locks:    make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+/// Функция Incr увеличивает счетчик key и возвращает число попыток в текущем окне window \\\
+
+func (d *MemoryStorage) Incr(ctx context.Context, key string, window time.Duration) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.cleanup(now)
+
+	c := d.counters[key]
+	if !now.Before(c.expiresAt) {
+		c = counter{expiresAt: now.Add(window)}
+	}
+	c.count++
+	d.counters[key] = c
+	return c.count, nil
+}
+
+/// Функция Reset сбрасывает счетчик и блокировку key \\\
+
+func (d *MemoryStorage) Reset(ctx context.Context, key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.counters, key)
+	delete(d.locks, key)
+	return nil
+}
+
+/// Функция Lock блокирует key до момента until \\\
+
+func (d *MemoryStorage) Lock(ctx context.Context, key string, until time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.locks[key] = until
+	return nil
+}
+
+/// Функция LockedUntil возвращает момент окончания блокировки key или нулевое время \\\
+
+func (d *MemoryStorage) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	until, ok := d.locks[key]
+	if !ok || !d.now().Before(until) {
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+/// Функция cleanup удаляет истекшие счетчики и блокировки, чтобы память не росла \\\
+
+func (d *MemoryStorage) cleanup(now time.Time) {
+	for key, c := range d.counters {
+		if !now.Before(c.expiresAt) {
+			delete(d.counters, key)
+		}
+	}
+	for key, until := range d.locks {
+		if !now.Before(until) {
+			delete(d.locks, key)
+		}
+	}
+}
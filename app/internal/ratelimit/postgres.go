@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"Interior_Visualization_Shop/app/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+var _ Storage = &PostgresStorage{}
+
+/// Структура PostgresStorage хранящая счетчики и блокировки в таблицах rate_limit и rate_limit_lock. \\\
+/// Счетчики проверяются на каждой попытке входа параллельно, поэтому хранилище работает через пул соединений \\\
+
+type PostgresStorage struct {
+	conn           *pgxpool.Pool
+	requestTimeout time.Duration
+}
+
+/// Структура NewPostgresStorage возвращает новый экземпляр PostgresStorage инициализируя переданные в него аргументы \\\
+
+func NewPostgresStorage(conn *pgxpool.Pool, requestTimeout int) Storage {
+	return &PostgresStorage{
+		conn:           conn,
+		requestTimeout: time.Duration(requestTimeout) * time.Second,
+	}
+}
+
+/// Функция Incr атомарно увеличивает счетчик key, начиная новое окно, если предыдущее истекло \\\
+
+func (d *PostgresStorage) Incr(ctx context.Context, key string, window time.Duration) (int, error) {
+	ctx, span := tracing.StartQuery(ctx, "incr_rate_limit")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var count int
+	err := d.conn.QueryRow(ctx,
+		`INSERT INTO rate_limit (key, count, expires_at)
+			 VALUES($1, 1, now() + make_interval(secs => $2))
+			 ON CONFLICT (key) DO UPDATE SET
+			 count      = CASE WHEN rate_limit.expires_at <= now() THEN 1 ELSE rate_limit.count + 1 END,
+			 expires_at = CASE WHEN rate_limit.expires_at <= now() THEN EXCLUDED.expires_at ELSE rate_limit.expires_at END
+			 RETURNING count`,
+		key, window.Seconds()).Scan(&count)
+	metrics.ObserveQuery("incr_rate_limit", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute incr rate limit query: %v", err)
+	}
+	return count, nil
+}
+
+/// Функция Reset удаляет счетчик и блокировку key \\\
+
+func (d *PostgresStorage) Reset(ctx context.Context, key string) error {
+	ctx, span := tracing.StartQuery(ctx, "reset_rate_limit")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := d.conn.Exec(ctx, `DELETE FROM rate_limit WHERE key = $1`, key)
+	if err == nil {
+		_, err = d.conn.Exec(ctx, `DELETE FROM rate_limit_lock WHERE key = $1`, key)
+	}
+	metrics.ObserveQuery("reset_rate_limit", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to reset rate limit: %v", err)
+	}
+	return nil
+}
+
+/// Функция Lock блокирует key до момента until \\\
+
+func (d *PostgresStorage) Lock(ctx context.Context, key string, until time.Time) error {
+	ctx, span := tracing.StartQuery(ctx, "lock_rate_limit")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := d.conn.Exec(ctx,
+		`INSERT INTO rate_limit_lock (key, locked_until)
+			 VALUES($1, $2)
+			 ON CONFLICT (key) DO UPDATE SET locked_until = EXCLUDED.locked_until`,
+		key, until)
+	metrics.ObserveQuery("lock_rate_limit", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to lock: %v", err)
+	}
+	return nil
+}
+
+/// Функция LockedUntil возвращает момент окончания блокировки key или нулевое время \\\
+
+func (d *PostgresStorage) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	ctx, span := tracing.StartQuery(ctx, "find_rate_limit_lock")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var until time.Time
+	err := d.conn.QueryRow(ctx,
+		`SELECT locked_until FROM rate_limit_lock
+			 WHERE key = $1 AND locked_until > now()`, key).Scan(&until)
+	metrics.ObserveQuery("find_rate_limit_lock", start, err)
+	tracing.RecordError(span, err)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to execute find lock query: %v", err)
+	}
+	return until, nil
+}
@@ -0,0 +1,16 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+/// Интерфейс Storage хранит счетчики попыток и блокировки. \\\
+/// Реализация в PostgreSQL позволяет разделять лимиты между несколькими экземплярами сервера \\\
+
+type Storage interface {
+	Incr(ctx context.Context, key string, window time.Duration) (int, error)
+	Reset(ctx context.Context, key string) error
+	Lock(ctx context.Context, key string, until time.Time) error
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+}
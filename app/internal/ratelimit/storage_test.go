@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"testing"
+	"time"
+)
+
+/// Функция testStorage - общий набор проверок, который проходит каждая реализация Storage \\\
+
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		got, err := s.Incr(ctx, "conformance:counter", time.Minute)
+		if err != nil {
+			t.Fatalf("Incr: %v", err)
+		}
+		if got != want {
+			t.Errorf("Incr #%d: got %d", want, got)
+		}
+	}
+	if got, err := s.Incr(ctx, "conformance:other", time.Minute); err != nil || got != 1 {
+		t.Errorf("Incr of another key: got %d, %v, want 1", got, err)
+	}
+
+	if err := s.Reset(ctx, "conformance:counter"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if got, err := s.Incr(ctx, "conformance:counter", time.Minute); err != nil || got != 1 {
+		t.Errorf("Incr after Reset: got %d, %v, want 1", got, err)
+	}
+
+	until, err := s.LockedUntil(ctx, "conformance:lock")
+	if err != nil || !until.IsZero() {
+		t.Errorf("LockedUntil before Lock: got %v, %v, want zero time", until, err)
+	}
+	lockedUntil := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err = s.Lock(ctx, "conformance:lock", lockedUntil); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if until, err = s.LockedUntil(ctx, "conformance:lock"); err != nil || !until.Equal(lockedUntil) {
+		t.Errorf("LockedUntil: got %v, %v, want %v", until, err, lockedUntil)
+	}
+	if err = s.Lock(ctx, "conformance:expired", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Lock in the past: %v", err)
+	}
+	if until, err = s.LockedUntil(ctx, "conformance:expired"); err != nil || !until.IsZero() {
+		t.Errorf("LockedUntil of an expired lock: got %v, %v, want zero time", until, err)
+	}
+
+	if err = s.Reset(ctx, "conformance:lock"); err != nil {
+		t.Fatalf("Reset lock: %v", err)
+	}
+	if until, err = s.LockedUntil(ctx, "conformance:lock"); err != nil || !until.IsZero() {
+		t.Errorf("LockedUntil after Reset: got %v, %v, want zero time", until, err)
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, NewMemoryStorage())
+}
+
+func TestMemoryStorageWindowExpires(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStorage().(*MemoryStorage)
+	s.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	s.Incr(ctx, "key", time.Minute)
+	s.Incr(ctx, "key", time.Minute)
+
+	now = now.Add(time.Minute)
+	if got, _ := s.Incr(ctx, "key", time.Minute); got != 1 {
+		t.Errorf("Incr after the window: got %d, want 1", got)
+	}
+}
+
+/// Функция TestPostgresStorage прогоняет набор на настоящей базе, если задана TEST_DATABASE_DSN \\\
+
+func TestPostgresStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	cleanup := func() {
+		if _, err := conn.Exec(ctx, `DELETE FROM rate_limit WHERE key LIKE 'conformance:%'`); err != nil {
+			t.Fatalf("cleanup: %v", err)
+		}
+		if _, err := conn.Exec(ctx, `DELETE FROM rate_limit_lock WHERE key LIKE 'conformance:%'`); err != nil {
+			t.Fatalf("cleanup: %v", err)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	testStorage(t, NewPostgresStorage(conn, 5))
+}
@@ -2,25 +2,67 @@ package response
 
 import (
 	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"encoding/json"
+	"errors"
 	"net/http"
 )
 
-func Error(w http.ResponseWriter, code int, message, developerMessage string) {
-	appError := apperror.NewAppError(code, message, developerMessage)
-	JSON(w, code, appError)
-}
-func BadRequest(w http.ResponseWriter, message, developerMessage string) {
-	Error(w, http.StatusBadRequest, message, developerMessage)
-}
+/// Тип содержимого ответов с ошибками по RFC 7807 \\\
+
+const ProblemContentType = "application/problem+json"
 
-func ErrorAuth(w http.ResponseWriter, message, developerMessage string) {
-	Error(w, http.StatusUnauthorized, message, developerMessage)
+/// Структура Problem - тело ответа с ошибкой по RFC 7807 \\\
+
+type Problem struct {
+	Type     string                `json:"type"`
+	Title    string                `json:"title"`
+	Status   int                   `json:"status"`
+	Detail   string                `json:"detail,omitempty"`
+	Instance string                `json:"instance,omitempty"`
+	Code     apperror.Kind         `json:"code"`
+	Errors   []apperror.FieldError `json:"errors,omitempty"`
 }
 
-func NotFound(w http.ResponseWriter) {
-	JSON(w, http.StatusNotFound, apperror.ErrNotFound)
+/// Функция Error - центральный обработчик ошибок: выбирает статус по виду ошибки err и пишет ответ application/problem+json. \\\
+/// Подробности внутренних ошибок пишутся только в лог, клиент получает общее сообщение \\\
+
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	problem := NewProblem(err)
+	problem.Instance = r.URL.Path
+
+	log := logger.GetLogger().FromContext(r.Context()).WithField("status", problem.Status)
+	if problem.Status >= http.StatusInternalServerError {
+		log.WithError(err).Error("request failed")
+	} else {
+		log.WithError(err).Warn("request rejected")
+	}
+
+	obj, err := json.Marshal(problem)
+	if err != nil {
+		return
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	w.Write(obj)
 }
 
-func InternalError(w http.ResponseWriter, message, developerMessage string) {
-	Error(w, http.StatusInternalServerError, message, developerMessage)
+/// Функция NewProblem переводит ошибку err в тело ответа Problem \\\
+
+func NewProblem(err error) Problem {
+	kind := apperror.KindOf(err)
+	status := kind.Status()
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   kind,
+	}
+
+	var appErr *apperror.AppError
+	if kind != apperror.KindInternal && errors.As(err, &appErr) {
+		problem.Detail = appErr.Message
+		problem.Errors = appErr.Fields
+	}
+	return problem
 }
@@ -0,0 +1,74 @@
+package response
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		detail string
+		fields int
+	}{
+		{"not found", apperror.ErrNotFound, http.StatusNotFound, "requested resource is not found", 0},
+		{"wrapped conflict", fmt.Errorf("cannot create user: %w", apperror.ErrRepeatedEmail), http.StatusConflict, "this email is already in use", 0},
+		{"unauthorized", apperror.ErrInvalidCredentials, http.StatusUnauthorized, "invalid email or password", 0},
+		{"forbidden", apperror.New(apperror.KindForbidden, "not allowed"), http.StatusForbidden, "not allowed", 0},
+		{"validation", apperror.Validation(
+			apperror.FieldError{Field: "email", Message: "must be a valid email address"},
+			apperror.FieldError{Field: "password", Message: "must be at least 8 characters long"},
+		), http.StatusBadRequest, "request validation failed", 2},
+		{"unavailable", apperror.Wrap(errors.New("conn busy"), apperror.KindUnavailable, apperror.ErrUnavailable.Message), http.StatusServiceUnavailable, "service is temporarily unavailable, try again later", 0},
+		{"plain error", errors.New("pq: connection refused"), http.StatusInternalServerError, "", 0},
+		{"wrapped internal", apperror.Wrap(errors.New("disk full"), apperror.KindInternal, "cannot save"), http.StatusInternalServerError, "", 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Error(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil), tc.err)
+
+			if rec.Code != tc.status {
+				t.Errorf("status: got %d, want %d", rec.Code, tc.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != ProblemContentType {
+				t.Errorf("Content-Type: got %q, want %q", ct, ProblemContentType)
+			}
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if problem.Status != tc.status || problem.Title != http.StatusText(tc.status) || problem.Instance != "/users/1" {
+				t.Errorf("problem: got %+v", problem)
+			}
+			if problem.Detail != tc.detail {
+				t.Errorf("detail: got %q, want %q", problem.Detail, tc.detail)
+			}
+			if len(problem.Errors) != tc.fields {
+				t.Errorf("errors: got %+v, want %d fields", problem.Errors, tc.fields)
+			}
+			if strings.Contains(rec.Body.String(), "connection refused") || strings.Contains(rec.Body.String(), "disk full") {
+				t.Errorf("internal cause leaked to the client: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestReadJSONReturnsValidationError(t *testing.T) {
+	var dest struct {
+		Email string `json:"email"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":`))
+	err := ReadJSON(httptest.NewRecorder(), req, &dest)
+	if apperror.KindOf(err) != apperror.KindValidation || !errors.Is(err, apperror.ErrInvalidRequestBody) {
+		t.Errorf("ReadJSON: got %v, want a validation error wrapping %v", err, apperror.ErrInvalidRequestBody)
+	}
+}
@@ -1,6 +1,8 @@
 package response
 
 import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/internal/validate"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,9 +24,29 @@ func JSON(w http.ResponseWriter, code int, data interface{}) {
 	w.Write(obj)
 }
 
-// readJSON decodes request body to the given destination(usually model struct).
-// Returns an error on failure.
+// ReadJSON decodes request body to the given destination(usually model struct),
+// then runs its Normalize and Validate methods when the destination implements
+// validate.Normalizer and validate.Validator.
+// Returns a validation *apperror.AppError on failure.
 func ReadJSON(w http.ResponseWriter, r *http.Request, dest interface{}) error {
+	if err := readJSON(w, r, dest); err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return apperror.Wrap(err, apperror.KindTooLarge, apperror.ErrRequestTooLarge.Message)
+		}
+		return apperror.Wrap(apperror.ErrInvalidRequestBody, apperror.KindValidation, err.Error())
+	}
+	if n, ok := dest.(validate.Normalizer); ok {
+		n.Normalize()
+	}
+	if v, ok := dest.(validate.Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// readJSON decodes request body to the given destination and describes what is wrong with it.
+func readJSON(w http.ResponseWriter, r *http.Request, dest interface{}) error {
 	// Create a new decoder and check for unknown fields
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
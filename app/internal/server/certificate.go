@@ -0,0 +1,106 @@
+package server
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+/// Структура certReloader отдает TLS сертификат из файлов и перечитывает их при изменении, без перезапуска сервера. \\\
+/// Если новые файлы не читаются (например, записан только сертификат без ключа), остается прежний сертификат \\\
+
+type certReloader struct {
+	log      logger.Logger
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+/// Функция newCertReloader загружает сертификат certFile с ключом keyFile \\\
+
+func newCertReloader(log logger.Logger, certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{
+		log:      log,
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if _, err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+/// Функция GetCertificate подставляется в tls.Config и вызывается при каждом TLS рукопожатии \\\
+
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+/// Функция reload перечитывает файлы, если время изменения одного из них стало новее загруженного \\\
+
+func (c *certReloader) reload() (bool, error) {
+	modTime, err := latestModTime(c.certFile, c.keyFile)
+	if err != nil {
+		return false, err
+	}
+	c.mu.RLock()
+	unchanged := c.cert != nil && !modTime.After(c.modTime)
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("cannot load TLS certificate: %v", err)
+	}
+	c.mu.Lock()
+	c.cert = &cert
+	c.modTime = modTime
+	c.mu.Unlock()
+	return true, nil
+}
+
+/// Функция watch проверяет файлы раз в interval, пока не закрыт stop \\\
+
+func (c *certReloader) watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			reloaded, err := c.reload()
+			if err != nil {
+				c.log.WithError(err).Error("keeping the previous TLS certificate")
+				continue
+			}
+			if reloaded {
+				c.log.WithField("cert_file", c.certFile).Info("reloaded TLS certificate")
+			}
+		}
+	}
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot read TLS file: %v", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
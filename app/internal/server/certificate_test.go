@@ -0,0 +1,134 @@
+package server
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+/// Функция writeCertificate записывает в dir самоподписанный сертификат для commonName и выставляет файлам время изменения modTime \\\
+
+func writeCertificate(t *testing.T, dir, commonName string, modTime time.Time) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{commonName},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{certFile, keyFile} {
+		if err := os.Chtimes(name, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, c *certReloader) string {
+	t.Helper()
+	cert, err := c.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloaderPicksUpRenewedFiles(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+	certFile, keyFile := writeCertificate(t, dir, "old.example", start)
+
+	c, err := newCertReloader(logger.GetLogger(), certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := commonName(t, c); got != "old.example" {
+		t.Fatalf("loaded %s", got)
+	}
+
+	if reloaded, err := c.reload(); err != nil || reloaded {
+		t.Fatalf("unchanged files: reloaded = %v, err = %v", reloaded, err)
+	}
+
+	writeCertificate(t, dir, "new.example", start.Add(time.Second))
+	if reloaded, err := c.reload(); err != nil || !reloaded {
+		t.Fatalf("renewed files: reloaded = %v, err = %v", reloaded, err)
+	}
+	if got := commonName(t, c); got != "new.example" {
+		t.Errorf("after reload got %s, want new.example", got)
+	}
+}
+
+func TestCertReloaderKeepsCertificateOnBrokenFiles(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+	certFile, keyFile := writeCertificate(t, dir, "old.example", start)
+
+	c, err := newCertReloader(logger.GetLogger(), certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.reload(); err == nil {
+		t.Fatal("broken key accepted")
+	}
+	if got := commonName(t, c); got != "old.example" {
+		t.Errorf("certificate replaced by a broken one: %s", got)
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	for _, tc := range []struct {
+		port, host, want string
+	}{
+		{port: "443", host: "vjm.example", want: "https://vjm.example/users?id=1"},
+		{port: "8443", host: "vjm.example:8080", want: "https://vjm.example:8443/users?id=1"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "http://"+tc.host+"/users?id=1", nil)
+		rec := httptest.NewRecorder()
+		redirectToHTTPS(tc.port).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != tc.want {
+			t.Errorf("port %s: %d %s, want 308 %s", tc.port, rec.Code, rec.Header().Get("Location"), tc.want)
+		}
+	}
+}
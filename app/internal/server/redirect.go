@@ -0,0 +1,23 @@
+package server
+
+import (
+	"net"
+	"net/http"
+)
+
+/// Функция redirectToHTTPS возвращает обработчик, который отправляет все запросы по HTTP на тот же адрес по HTTPS. \\\
+/// httpsPort добавляется к адресу, если он не стандартный 443 \\\
+
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		/// 308 сохраняет метод и тело запроса, в отличие от 301 \\\
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
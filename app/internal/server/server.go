@@ -3,119 +3,326 @@ package server
 import (
 	"Interior_Visualization_Shop/app/internal/appeal"
 	"Interior_Visualization_Shop/app/internal/auth"
+	"Interior_Visualization_Shop/app/internal/catalog"
+	"Interior_Visualization_Shop/app/internal/health"
+	"Interior_Visualization_Shop/app/internal/mail"
+	"Interior_Visualization_Shop/app/internal/middleware"
+	authmw "Interior_Visualization_Shop/app/internal/middleware/auth"
+	"Interior_Visualization_Shop/app/internal/ratelimit"
+	"Interior_Visualization_Shop/app/internal/site"
+	"Interior_Visualization_Shop/app/internal/static"
 	"Interior_Visualization_Shop/app/internal/user"
 	"Interior_Visualization_Shop/app/pkg/config"
 	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"Interior_Visualization_Shop/public"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/julienschmidt/httprouter"
-	"github.com/pkg/browser"
-	"github.com/rs/cors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net"
 	"net/http"
+	"os"
 	"time"
 )
 
+/// Максимальное время проверки зависимостей в /readyz \\\
+
+const readinessTimeout = 3 * time.Second
+
 type Server struct {
-	srv     *http.Server
-	log     *logger.Logger
-	cfg     *config.Config
-	handler *httprouter.Router
+	srv      *http.Server
+	log      *logger.Logger
+	cfg      *config.Config
+	handler  *httprouter.Router
+	redirect *http.Server
+	metrics  *http.Server
+	ready    chan struct{}
+	stop     chan struct{}
 }
 
 func NewServer(cfg *config.Config, handler *httprouter.Router, log *logger.Logger) *Server {
 
-	/// список разрешенных источников (доменов), заголовков и HTTP-методов, которые разрешены для выполнения на сервере \\\
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:63342"},
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
-		AllowCredentials: true,
-	})
+	/// Цепочка middleware общая для всех обработчиков handler.Hand, первый в списке выполняется первым: \\\
+	/// спан трассировки, идентификатор запроса и логгер с request_id, строка лога на запрос, метрики по шаблонам \\\
+	/// маршрутов, перехват паники, CORS, заголовки безопасности, лимит размера тела и сжатие. \\\
+	/// Лог и метрики стоят снаружи Recovery, чтобы запрос с паникой тоже попал в них с кодом 500 \\\
+	middlewares := []middleware.Middleware{
+		middleware.Tracing(handler),
+		middleware.RequestID(*log),
+		middleware.Logging(*log),
+		middleware.Metrics(handler),
+		middleware.Recovery(*log),
+		middleware.CORS(middleware.CORSOptions{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			ExposedHeaders:   cfg.CORS.ExposedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		}),
+		middleware.SecurityHeaders(cfg.HTTP.ContentSecurityPolicy),
+		middleware.BodyLimit(cfg.HTTP.MaxBodyBytes),
+	}
+	if cfg.HTTP.Compress {
+		middlewares = append(middlewares, middleware.Compress())
+	}
+	if cfg.TLS.Enabled && cfg.TLS.HSTSMaxAge > 0 {
+		middlewares = append(middlewares, middleware.HSTS(cfg.TLS.HSTSMaxAge, cfg.TLS.HSTSIncludeSubdomains))
+	}
+
+	srv := &http.Server{
+		Handler:      middleware.Chain(handler, middlewares...),
+		WriteTimeout: time.Duration(cfg.HTTP.WriteTimeout) * time.Second,
+		ReadTimeout:  time.Duration(cfg.HTTP.ReadTimeout) * time.Second,
+		Addr:         fmt.Sprintf("%s:%s", cfg.HTTP.Host, cfg.HTTP.Port),
+	}
 
-	handlerWithCORS := c.Handler(handler)
+	/// HTTP/2 по TLS включен в net/http по умолчанию, пустая TLSNextProto его отключает. \\\
+	/// h2c - HTTP/2 без TLS для работы за прокси, который сам снимает TLS \\\
+	if !cfg.HTTP.HTTP2 {
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	} else if cfg.HTTP.H2C && !cfg.TLS.Enabled {
+		srv.Handler = h2c.NewHandler(srv.Handler, &http2.Server{})
+	}
 
-	return &Server{
-		srv: &http.Server{
-			Handler:      handlerWithCORS,
-			WriteTimeout: time.Duration(cfg.HTTP.WriteTimeout) * time.Second,
-			ReadTimeout:  time.Duration(cfg.HTTP.ReadTimeout) * time.Second,
-			Addr:         fmt.Sprintf("%s:%s", cfg.HTTP.Host, cfg.HTTP.Port),
-		},
+	s := &Server{
+		srv:     srv,
 		log:     log,
 		cfg:     cfg,
 		handler: handler,
+		ready:   make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+
+	/// Отдельный слушатель на HTTP отвечает только перенаправлением на HTTPS \\\
+	if cfg.TLS.Enabled && cfg.TLS.RedirectAddr != "" {
+		s.redirect = &http.Server{
+			Addr:         cfg.TLS.RedirectAddr,
+			Handler:      redirectToHTTPS(cfg.HTTP.Port),
+			ReadTimeout:  srv.ReadTimeout,
+			WriteTimeout: srv.WriteTimeout,
+		}
 	}
+
+	/// Метрики Prometheus слушают отдельный адрес, закрытый от публичного порта сайта \\\
+	if cfg.Metrics.Addr != "" {
+		s.metrics = &http.Server{
+			Addr:         cfg.Metrics.Addr,
+			Handler:      metrics.Handler(),
+			ReadTimeout:  srv.ReadTimeout,
+			WriteTimeout: srv.WriteTimeout,
+		}
+	}
+	return s
+}
+
+/// Функция Ready возвращает канал, который закрывается, когда сервер начал принимать соединения \\\
+
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+/// Функция URL возвращает адрес главной страницы сервера \\\
+
+func (s *Server) URL() string {
+	if s.cfg.TLS.Enabled {
+		return "https://" + s.srv.Addr + "/"
+	}
+	return "http://" + s.srv.Addr + "/"
 }
 
 /// Функция инициализирующая хранище storage, сервисы services и обработчики handler \\\
-/// Запускает сервер и начинает обрабатывать входящие HTTP запросы \\\
+/// Запускает сервер и начинает обрабатывать входящие HTTP запросы. \\\
+/// Все хранилища PostgreSQL работают через пул соединений dbPool \\\
 
-func (s *Server) Run(dbConn *pgx.Conn) error {
+func (s *Server) Run(dbPool *pgxpool.Pool) error {
 
 	reqTimeout := s.cfg.PostgreSQL.RequestTimeout
 
-	/// Инициализация хранилища userStorage, создание объекта сервиса userService, создание обработчика userHandler для пользователей \\\
+	/// Выбор хранилища: PostgreSQL или память процесса для демонстрационного режима \\\
+	var userStorage user.Storage
+	var appealStorage appeal.Storage
+	var catalogStorage catalog.Storage
+	var verificationStorage auth.VerificationStorage
+	var sessionStorage auth.SessionStorage
+	if s.cfg.Storage.Type == config.StorageMemory {
+		userStorage = user.NewMemoryStorage()
+		appealStorage = appeal.NewMemoryStorage()
+		catalogStorage = catalog.NewMemoryStorage(catalog.DefaultServices(), catalog.DefaultWorks())
+		verificationStorage = auth.NewMemoryVerificationStorage()
+		sessionStorage = auth.NewMemorySessionStorage()
+		s.log.Info("using in-memory storage")
+	} else {
+		userStorage = user.NewStorage(dbPool, reqTimeout)
+		appealStorage = appeal.NewStorage(dbPool, reqTimeout)
+		catalogStorage = catalog.NewStorage(dbPool, reqTimeout)
+		verificationStorage = auth.NewPostgresVerificationStorage(dbPool, reqTimeout)
+		sessionStorage = auth.NewPostgresSessionStorage(dbPool, reqTimeout)
+	}
+
+	mailSender := mail.NewSender(s.cfg.MAIL.MailAddress, s.cfg.MAIL.MailPassword.Value())
+
+	/// Каталог для документов, прикрепленных к обращениям \\\
+	if err := os.MkdirAll(s.cfg.Blob.Dir, 0755); err != nil {
+		return fmt.Errorf("cannot create blob directory: %v", err)
+	}
+
+	/// Проверки живости и готовности: БД, почтовый сервис и каталог документов \\\
+	checks := map[string]health.Check{
+		"mail": mailSender.Ping,
+		"blob": health.DirWritable(s.cfg.Blob.Dir),
+	}
+	if dbPool != nil {
+		checks["postgres"] = dbPool.Ping
+	}
+	healthHandler := health.NewHandler(*s.log, checks, readinessTimeout)
+	healthHandler.Register(s.handler)
+	s.log.Info("initialized health routes")
+
+	/// Создание объекта сервиса userService, создание обработчика userHandler для пользователей \\\
 	/// Тот же принцип работы для остальных route \\\
 
-	userStorage := user.NewStorage(dbConn, reqTimeout)
+	/// Токены доступа проверяет общий Authenticator: из заголовка Authorization, а в режиме браузера и из HttpOnly cookie \\\
+	tokens, err := auth.NewTokens(*s.cfg)
+	if err != nil {
+		return fmt.Errorf("cannot load JWT keys: %v", err)
+	}
+	var cookies authmw.Options
+	if s.cfg.Auth.Cookies {
+		cookies = authmw.Options{
+			AccessCookie: s.cfg.Auth.AccessCookie,
+			CSRFCookie:   s.cfg.Auth.CSRFCookie,
+			CSRFHeader:   s.cfg.Auth.CSRFHeader,
+		}
+	}
+	authn := authmw.New(*s.log, tokens.Access, cookies)
+
 	userService := user.NewService(userStorage, *s.log)
-	userHandler := user.NewHandler(*s.log, userService)
+	userHandler := user.NewHandler(*s.log, userService, authn)
 	userHandler.Register(s.handler)
 	s.log.Info("initialized user routes")
 
-	authStorage := user.NewStorage(dbConn, reqTimeout)
-	authService := auth.NewService(authStorage, *s.log, *s.cfg)
-	authHandler := auth.NewHandler(*s.log, authService, *s.cfg)
+	/// Ограничение частоты попыток входа и регистрации, в PostgreSQL лимиты общие для всех экземпляров \\\
+	var limitStorage ratelimit.Storage
+	if s.cfg.RateLimit.Storage == config.StoragePostgres && dbPool != nil {
+		limitStorage = ratelimit.NewPostgresStorage(dbPool, reqTimeout)
+	} else {
+		limitStorage = ratelimit.NewMemoryStorage()
+	}
+	limiter := ratelimit.NewLimiter(*s.log, limitStorage, ratelimit.Options{
+		Enabled:        s.cfg.RateLimit.Enabled,
+		Window:         time.Duration(s.cfg.RateLimit.Window) * time.Second,
+		IPLimit:        s.cfg.RateLimit.IPLimit,
+		EmailLimit:     s.cfg.RateLimit.EmailLimit,
+		MaxFailures:    s.cfg.RateLimit.MaxFailures,
+		FailureWindow:  time.Duration(s.cfg.RateLimit.FailureWindow) * time.Second,
+		LockoutBase:    time.Duration(s.cfg.RateLimit.LockoutBase) * time.Second,
+		LockoutMax:     time.Duration(s.cfg.RateLimit.LockoutMax) * time.Second,
+		TrustForwarded: s.cfg.RateLimit.TrustForwarded,
+	})
+
+	authService := auth.NewService(userStorage, sessionStorage, *s.log, tokens, s.cfg.Auth.AdminEmails)
+	authHandler := auth.NewHandler(*s.log, authService, *s.cfg, mailSender, limiter, verificationStorage, tokens.Access.JWKS())
 	authHandler.Register(s.handler)
 	s.log.Info("initialized auth routes")
 
-	appealStorage := appeal.NewStorage(dbConn, reqTimeout)
 	appealService := appeal.NewService(appealStorage, *s.log)
-	appealHandler := appeal.NewHandler(*s.log, appealService, *s.cfg, authService)
+	appealHandler := appeal.NewHandler(*s.log, appealService, *s.cfg, authn, mailSender)
 	appealHandler.Register(s.handler)
 	s.log.Info("initialized appeal routes")
 
-	/// создание файлового сервера для статических файлов, которые находятся в директории "public" \\\
-	fs := http.FileServer(http.Dir("public"))
-	s.handler.Handler(http.MethodGet, "/", fs)
-
-	/// обработка запросов для пути "/style.css", обслуживаеться файловым сервером fs \\\
-	s.handler.Handler(http.MethodGet, "/style.css", fs)
-	s.handler.Handler(http.MethodGet, "/index.html", fs)
-	s.handler.Handler(http.MethodGet, "/appeal.html", fs)
-	s.handler.Handler(http.MethodGet, "/contacts.html", fs)
-	s.handler.Handler(http.MethodGet, "/portfolio.html", fs)
-	s.handler.Handler(http.MethodGet, "/service.html", fs)
-	s.handler.Handler(http.MethodGet, "/sign-in.html", fs)
-	s.handler.Handler(http.MethodGet, "/sign-up.html", fs)
-	s.handler.Handler(http.MethodGet, "/1.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/2.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/3.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/4.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/5.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/6.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/7.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/8.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/9.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/10.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/tel.png", fs)
-	s.handler.Handler(http.MethodGet, "/vk.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/wp.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/gm.jpg", fs)
-	s.handler.Handler(http.MethodGet, "/clients-bg.jpg", fs)
-
-	/// открытие веб-страницы в браузере \\\
-	err := browser.OpenURL("http://" + s.srv.Addr + "/")
+	/// файлы сайта встроены в бинарный файл и отдаются по всем путям, для которых нет маршрутов API \\\
+	staticHandler, err := static.NewHandler(*s.log, public.Files, s.cfg.Static.Dir)
+	if err != nil {
+		return fmt.Errorf("cannot load static files: %v", err)
+	}
+
+	/// страницы сайта строятся из каталога услуг и портфолио, вместе с sitemap.xml и robots.txt. \\\
+	/// Ошибки страниц отдаются страницами 404.html и 500.html из файлов сайта \\\
+	siteHandler, err := site.NewHandler(*s.log, catalogStorage, staticHandler, site.Options{
+		Name:        s.cfg.Site.Name,
+		Description: s.cfg.Site.Description,
+		BaseURL:     s.cfg.Site.BaseURL,
+		Phone:       s.cfg.Site.Phone,
+		Email:       s.cfg.Site.Email,
+		Image:       s.cfg.Site.Image,
+		Locale:      s.cfg.Site.Locale,
+		Currency:    s.cfg.Site.Currency,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot parse page templates: %v", err)
+	}
+	siteHandler.Register(s.handler)
+	s.log.Info("initialized site pages")
+
+	staticHandler.Register(s.handler)
+	s.log.Info("initialized static files")
+
+	/// метрики Prometheus: HTTP запросы, запросы к БД, отправка писем и воронка регистрации \\\
+	if s.metrics != nil {
+		go func() {
+			if err := s.metrics.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.log.WithError(err).Error("cannot run the metrics listener")
+			}
+		}()
+		s.log.WithField("addr", s.metrics.Addr).Info("serving metrics")
+	}
+
+	/// порт занимается до закрытия ready, чтобы после Ready запросы к серверу уже принимались \\\
+	if !s.cfg.TLS.Enabled {
+		ln, err := net.Listen("tcp", s.srv.Addr)
+		if err != nil {
+			return err
+		}
+		close(s.ready)
+		return s.srv.Serve(ln)
+	}
+
+	/// сертификат перечитывается с диска при обновлении, например после продления certbot \\\
+	certs, err := newCertReloader(*s.log, s.cfg.TLS.CertFile, s.cfg.TLS.KeyFile)
 	if err != nil {
 		return err
 	}
+	go certs.watch(time.Duration(s.cfg.TLS.ReloadInterval)*time.Second, s.stop)
+	s.srv.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
 
-	return s.srv.ListenAndServe()
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	if s.redirect != nil {
+		go func() {
+			if err := s.redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.log.WithError(err).Error("cannot run the HTTPS redirect listener")
+			}
+		}()
+		s.log.WithField("addr", s.redirect.Addr).Info("redirecting plain HTTP to HTTPS")
+	}
+	close(s.ready)
+	return s.srv.ServeTLS(ln, "", "")
 }
 
 /// Метоод Shutdown структуры Server. Функция для завершения работы сервера \\\
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	close(s.stop)
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			s.log.WithError(err).Error("redirect listener shutdown failed")
+		}
+	}
+	if s.metrics != nil {
+		if err := s.metrics.Shutdown(ctx); err != nil {
+			s.log.WithError(err).Error("metrics listener shutdown failed")
+		}
+	}
 	return s.srv.Shutdown(ctx)
 }
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"Interior_Visualization_Shop/app/pkg/config"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPanicIsCountedInMetrics(t *testing.T) {
+	var cfg config.Config
+	cfg.HTTP.MaxBodyBytes = 1 << 20
+	cfg.HTTP.Compress = true
+	router := httprouter.New()
+	router.GET("/panic/:id", func(http.ResponseWriter, *http.Request, httprouter.Params) {
+		panic("boom")
+	})
+	log := logger.GetLogger()
+	s := NewServer(&cfg, router, &log)
+
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic/1", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	scrape := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := `route="/panic/:id",status="500"} 1`; !strings.Contains(scrape.Body.String(), want) {
+		t.Errorf("the panicking request is missing from the metrics, want %s", want)
+	}
+}
@@ -0,0 +1,221 @@
+package site
+
+import (
+	"Interior_Visualization_Shop/app/internal/catalog"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/tracing"
+	"bytes"
+	"embed"
+	"github.com/julienschmidt/httprouter"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+/// Шаблоны страниц, встроенные в бинарный файл. layout.html содержит общий head с SEO разметкой \\\
+
+//go:embed templates/*.html
+var templates embed.FS
+
+const (
+	sitemapURL = "/sitemap.xml"
+	robotsURL  = "/robots.txt"
+	/// Работы портфолио раскладываются в колонки по worksPerColumn \\\
+	worksPerColumn = 2
+)
+
+/// Структура Options описывает сайт для страниц, OpenGraph и JSON-LD \\\
+/// BaseURL - публичный адрес сайта, из него строятся канонические ссылки и sitemap.xml \\\
+
+type Options struct {
+	Name        string
+	Description string
+	BaseURL     string
+	Phone       string
+	Email       string
+	Image       string
+	Locale      string
+	Currency    string
+}
+
+/// Интерфейс ErrorPages отвечает браузеру HTML страницей ошибки err вместо application/problem+json \\\
+
+type ErrorPages interface {
+	ErrorPage(w http.ResponseWriter, r *http.Request, err error)
+}
+
+/// Структура Handler строит страницы сайта из каталога услуг и портфолио при каждом запросе \\\
+
+type Handler struct {
+	log     logger.Logger
+	catalog catalog.Storage
+	errors  ErrorPages
+	opts    Options
+	tmpl    map[string]*template.Template
+}
+
+/// Структура pageData - данные шаблона страницы \\\
+
+type pageData struct {
+	Site        Options
+	Title       string
+	Description string
+	Path        string
+	Image       string
+	JSONLD      template.JS
+	Services    []catalog.Service
+	WorkColumns [][]catalog.Work
+}
+
+/// Функция NewHandler разбирает встроенные шаблоны страниц и возвращает обработчик. \\\
+/// Ошибки при построении страниц отдаются страницами errors \\\
+
+func NewHandler(log logger.Logger, storage catalog.Storage, errors ErrorPages, opts Options) (*Handler, error) {
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	h := &Handler{
+		log:     log,
+		catalog: storage,
+		errors:  errors,
+		opts:    opts,
+		tmpl:    make(map[string]*template.Template),
+	}
+	funcs := template.FuncMap{"url": h.url}
+	for _, page := range []string{"index.html", "service.html", "portfolio.html"} {
+		t, err := template.New(page).Funcs(funcs).ParseFS(templates, "templates/layout.html", "templates/"+page)
+		if err != nil {
+			return nil, err
+		}
+		h.tmpl[page] = t
+	}
+	return h, nil
+}
+
+/// Функция Register регистрирует страницы на GET и HEAD: HEAD делают проверки доступности и поисковые роботы \\\
+
+func (h *Handler) Register(router *httprouter.Router) {
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		router.HandlerFunc(method, "/", h.Index)
+		router.HandlerFunc(method, "/index.html", h.Index)
+		router.HandlerFunc(method, "/service.html", h.Services)
+		router.HandlerFunc(method, "/portfolio.html", h.Portfolio)
+		router.HandlerFunc(method, sitemapURL, h.Sitemap)
+		router.HandlerFunc(method, robotsURL, h.Robots)
+	}
+}
+
+/// Функция Index строит главную страницу \\\
+
+func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "site.Handler.Index")
+	defer span.End()
+	h.log.FromContext(ctx).Info("RENDER INDEX PAGE")
+
+	services, err := h.catalog.Services(ctx)
+	if err != nil {
+		h.errors.ErrorPage(w, r, err)
+		return
+	}
+	h.render(w, r, "index.html", pageData{
+		Title:       "Interior Visualization",
+		Description: h.opts.Description,
+		Path:        "/",
+		Image:       h.opts.Image,
+		JSONLD:      h.jsonLD(localBusiness(h.opts, h.url)),
+		Services:    services,
+	})
+}
+
+/// Функция Services строит страницу услуг из каталога \\\
+
+func (h *Handler) Services(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "site.Handler.Services")
+	defer span.End()
+	h.log.FromContext(ctx).Info("RENDER SERVICE PAGE")
+
+	services, err := h.catalog.Services(ctx)
+	if err != nil {
+		h.errors.ErrorPage(w, r, err)
+		return
+	}
+	image := h.opts.Image
+	if len(services) > 0 {
+		image = services[0].Image
+	}
+	h.render(w, r, "service.html", pageData{
+		Title:       "Service",
+		Description: h.opts.Name + " services: " + serviceNames(services) + ".",
+		Path:        "/service.html",
+		Image:       image,
+		JSONLD:      h.jsonLD(offerCatalog(h.opts, h.url, services)),
+		Services:    services,
+	})
+}
+
+/// Функция Portfolio строит страницу портфолио \\\
+
+func (h *Handler) Portfolio(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracing.Start(r.Context(), "site.Handler.Portfolio")
+	defer span.End()
+	h.log.FromContext(ctx).Info("RENDER PORTFOLIO PAGE")
+
+	works, err := h.catalog.Works(ctx)
+	if err != nil {
+		h.errors.ErrorPage(w, r, err)
+		return
+	}
+	image := h.opts.Image
+	if len(works) > 0 {
+		image = works[0].Image
+	}
+	h.render(w, r, "portfolio.html", pageData{
+		Title:       "Portfolio",
+		Description: "Some of my work: interior renders and 3D models.",
+		Path:        "/portfolio.html",
+		Image:       image,
+		JSONLD:      h.jsonLD(localBusiness(h.opts, h.url)),
+		WorkColumns: columns(works, worksPerColumn),
+	})
+}
+
+/// Функция render выполняет шаблон page целиком в буфер, чтобы ошибка шаблона не оставила клиенту половину страницы \\\
+
+func (h *Handler) render(w http.ResponseWriter, r *http.Request, page string, data pageData) {
+	data.Site = h.opts
+	var buf bytes.Buffer
+	if err := h.tmpl[page].Execute(&buf, data); err != nil {
+		h.errors.ErrorPage(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+/// Функция url строит абсолютный адрес страницы или файла сайта path \\\
+
+func (h *Handler) url(path string) string {
+	return h.opts.BaseURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+func serviceNames(services []catalog.Service) string {
+	names := make([]string, len(services))
+	for i, s := range services {
+		names[i] = strings.ToLower(s.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+/// Функция columns раскладывает работы по колонкам из n штук \\\
+
+func columns(works []catalog.Work, n int) [][]catalog.Work {
+	var cols [][]catalog.Work
+	for len(works) > n {
+		cols = append(cols, works[:n])
+		works = works[n:]
+	}
+	if len(works) > 0 {
+		cols = append(cols, works)
+	}
+	return cols
+}
@@ -0,0 +1,186 @@
+package site
+
+import (
+	"Interior_Visualization_Shop/app/internal/catalog"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testOptions = Options{
+	Name:        "VJ&M Interior Visualization",
+	Description: "Freelance interior visualization",
+	BaseURL:     "https://vjm.example/",
+	Phone:       "+7 952 020 81 66",
+	Email:       "vasileva.julia02@gmail.com",
+	Image:       "9.jpg",
+	Locale:      "en_US",
+	Currency:    "RUB",
+}
+
+/// Структура htmlErrors заменяет статические страницы ошибок \\\
+
+type htmlErrors struct{}
+
+func (htmlErrors) ErrorPage(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte("<h1>Something went wrong</h1>"))
+}
+
+/// Структура failingCatalog отвечает ошибкой на каждый запрос, как недоступная база \\\
+
+type failingCatalog struct{}
+
+func (failingCatalog) Services(ctx context.Context) ([]catalog.Service, error) {
+	return nil, errors.New("conn busy")
+}
+
+func (failingCatalog) Works(ctx context.Context) ([]catalog.Work, error) {
+	return nil, errors.New("conn busy")
+}
+
+func newTestRouter(t *testing.T, services []catalog.Service, works []catalog.Work) *httprouter.Router {
+	return newTestRouterWithStorage(t, catalog.NewMemoryStorage(services, works))
+}
+
+func newTestRouterWithStorage(t *testing.T, storage catalog.Storage) *httprouter.Router {
+	h, err := NewHandler(logger.GetLogger(), storage, htmlErrors{}, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := httprouter.New()
+	h.Register(router)
+	return router
+}
+
+func get(router http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+var jsonLDPattern = regexp.MustCompile(`(?s)<script type="application/ld\+json">(.*?)</script>`)
+
+func TestServicePageFromCatalog(t *testing.T) {
+	services := []catalog.Service{
+		{ID: 1, Name: "Kitchen <design>", Description: "Renders", Price: "7000", Image: "3.jpg"},
+	}
+	rec := get(newTestRouter(t, services, nil), "/service.html")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`Kitchen &lt;design&gt;`,
+		`from 7000 RUB`,
+		`<link rel="canonical" href="https://vjm.example/service.html">`,
+		`<meta property="og:image" content="https://vjm.example/3.jpg">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not contain %s", want)
+		}
+	}
+
+	m := jsonLDPattern.FindStringSubmatch(body)
+	if m == nil {
+		t.Fatal("no JSON-LD block")
+	}
+	var ld struct {
+		Type    string `json:"@type"`
+		Catalog struct {
+			Items []struct {
+				Price   string `json:"price"`
+				Service struct {
+					Type string `json:"@type"`
+					Name string `json:"name"`
+				} `json:"itemOffered"`
+			} `json:"itemListElement"`
+		} `json:"hasOfferCatalog"`
+	}
+	if err := json.Unmarshal([]byte(m[1]), &ld); err != nil {
+		t.Fatalf("JSON-LD is not valid JSON: %v\n%s", err, m[1])
+	}
+	if ld.Type != "LocalBusiness" || len(ld.Catalog.Items) != 1 ||
+		ld.Catalog.Items[0].Service.Type != "Service" || ld.Catalog.Items[0].Service.Name != "Kitchen <design>" ||
+		ld.Catalog.Items[0].Price != "7000" {
+		t.Errorf("unexpected JSON-LD: %+v", ld)
+	}
+	if strings.Contains(m[1], "<design>") {
+		t.Error("catalog data is not escaped inside the script tag")
+	}
+}
+
+func TestIndexAndPortfolio(t *testing.T) {
+	router := newTestRouter(t, catalog.DefaultServices(), catalog.DefaultWorks())
+
+	for _, target := range []string{"/", "/index.html"} {
+		body := get(router, target).Body.String()
+		if !strings.Contains(body, testOptions.Phone) || !strings.Contains(body, "Creation of 3d models, Animation") {
+			t.Errorf("GET %s does not show the contacts and services", target)
+		}
+		if !strings.Contains(body, `<link rel="canonical" href="https://vjm.example/">`) {
+			t.Errorf("GET %s has a wrong canonical link", target)
+		}
+	}
+
+	body := get(router, "/portfolio.html").Body.String()
+	if n := strings.Count(body, `class="works__item"`); n != len(catalog.DefaultWorks()) {
+		t.Errorf("portfolio shows %d works, want %d", n, len(catalog.DefaultWorks()))
+	}
+	if n := strings.Count(body, `class="works__col"`); n != 4 {
+		t.Errorf("portfolio has %d columns, want 4", n)
+	}
+}
+
+func TestSitemapAndRobots(t *testing.T) {
+	router := newTestRouter(t, nil, nil)
+
+	rec := get(router, "/sitemap.xml")
+	var set struct {
+		URLs []struct {
+			Loc string `xml:"loc"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("sitemap is not valid XML: %v", err)
+	}
+	if len(set.URLs) != len(sitemapPages) || set.URLs[0].Loc != "https://vjm.example/" {
+		t.Errorf("unexpected sitemap: %+v", set)
+	}
+
+	body := get(router, "/robots.txt").Body.String()
+	if !strings.Contains(body, "Disallow: /protected/") || !strings.Contains(body, "Sitemap: https://vjm.example/sitemap.xml") {
+		t.Errorf("unexpected robots.txt:\n%s", body)
+	}
+}
+
+func TestPagesWithFailingCatalog(t *testing.T) {
+	router := newTestRouterWithStorage(t, failingCatalog{})
+	for _, target := range []string{"/", "/service.html", "/portfolio.html"} {
+		rec := get(router, target)
+		if rec.Code != http.StatusInternalServerError || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+			t.Errorf("GET %s: got %d %s, want the HTML error page", target, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestHead(t *testing.T) {
+	router := newTestRouter(t, catalog.DefaultServices(), catalog.DefaultWorks())
+	for _, target := range []string{"/", "/index.html", "/service.html", "/portfolio.html", "/sitemap.xml", "/robots.txt"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, target, nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") == "" {
+			t.Errorf("HEAD %s: got %d %q", target, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package site
+
+import (
+	"Interior_Visualization_Shop/app/internal/catalog"
+	"encoding/json"
+	"encoding/xml"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+/// Страницы сайта, которые попадают в sitemap.xml \\\
+
+var sitemapPages = []struct {
+	path       string
+	changeFreq string
+	priority   string
+}{
+	{path: "/", changeFreq: "weekly", priority: "1.0"},
+	{path: "/service.html", changeFreq: "weekly", priority: "0.8"},
+	{path: "/portfolio.html", changeFreq: "weekly", priority: "0.8"},
+	{path: "/contacts.html", changeFreq: "monthly", priority: "0.5"},
+	{path: "/appeal.html", changeFreq: "monthly", priority: "0.5"},
+}
+
+/// Пути API, которые не нужно индексировать \\\
+
+var robotsDisallow = []string{"/protected/", "/users", "/sign_in", "/sign_up", "/healthz", "/readyz"}
+
+/// Функция localBusiness описывает сайт разметкой schema.org LocalBusiness \\\
+
+func localBusiness(opts Options, url func(string) string) map[string]interface{} {
+	return map[string]interface{}{
+		"@context":    "https://schema.org",
+		"@type":       "LocalBusiness",
+		"name":        opts.Name,
+		"description": opts.Description,
+		"url":         url("/"),
+		"telephone":   opts.Phone,
+		"email":       opts.Email,
+		"image":       url(opts.Image),
+	}
+}
+
+/// Функция offerCatalog дополняет LocalBusiness каталогом услуг schema.org Service с ценами \\\
+
+func offerCatalog(opts Options, url func(string) string, services []catalog.Service) map[string]interface{} {
+	offers := make([]map[string]interface{}, len(services))
+	for i, s := range services {
+		offer := map[string]interface{}{
+			"@type": "Offer",
+			"itemOffered": map[string]interface{}{
+				"@type":       "Service",
+				"name":        s.Name,
+				"description": s.Description,
+				"image":       url(s.Image),
+				"url":         url("/service.html"),
+			},
+		}
+		if s.Price != "" {
+			offer["price"] = s.Price
+			offer["priceCurrency"] = opts.Currency
+		}
+		offers[i] = offer
+	}
+
+	business := localBusiness(opts, url)
+	business["hasOfferCatalog"] = map[string]interface{}{
+		"@type":           "OfferCatalog",
+		"name":            "Services",
+		"itemListElement": offers,
+	}
+	return business
+}
+
+/// Функция jsonLD сериализует разметку v для тега script. json.Marshal экранирует <, > и &, \\\
+/// поэтому данные каталога не могут закрыть тег \\\
+
+func (h *Handler) jsonLD(v interface{}) template.JS {
+	b, err := json.Marshal(v)
+	if err != nil {
+		h.log.WithError(err).Error("cannot marshal JSON-LD")
+		return ""
+	}
+	return template.JS(b)
+}
+
+type urlSet struct {
+	XMLName xml.Name       `xml:"urlset"`
+	Xmlns   string         `xml:"xmlns,attr"`
+	URLs    []sitemapEntry `xml:"url"`
+}
+
+type sitemapEntry struct {
+	Loc        string `xml:"loc"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+/// Функция Sitemap отдает sitemap.xml с публичными страницами сайта \\\
+
+func (h *Handler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	set := urlSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, p := range sitemapPages {
+		set.URLs = append(set.URLs, sitemapEntry{Loc: h.url(p.path), ChangeFreq: p.changeFreq, Priority: p.priority})
+	}
+
+	b, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		h.log.WithError(err).Error("cannot marshal sitemap")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	w.Write(b)
+}
+
+/// Функция Robots отдает robots.txt: API закрыт от индексации, указан адрес sitemap.xml \\\
+
+func (h *Handler) Robots(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, p := range robotsDisallow {
+		b.WriteString("Disallow: " + p + "\n")
+	}
+	b.WriteString("\nSitemap: " + h.url(sitemapURL) + "\n")
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,117 @@
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/// Имена файлов с отпечатком содержимого: style.3f2a9c1b.css. Такие файлы не меняются и кэшируются надолго \\\
+
+var fingerprintPattern = regexp.MustCompile(`\.[0-9a-f]{8,}\.[^.]+$`)
+
+/// Сжатые варианты файла, которые ищутся рядом с ним, в порядке предпочтения \\\
+
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{name: "br", ext: ".br"},
+	{name: "gzip", ext: ".gz"},
+}
+
+/// Структура asset - файл сайта, подготовленный к отдаче: тип, ETag и сжатые варианты \\\
+
+type asset struct {
+	name        string
+	contentType string
+	etag        string
+	modTime     time.Time
+	immutable   bool
+	data        []byte
+	variants    map[string][]byte
+}
+
+/// Функция loadAsset читает файл name из fsys вместе со сжатыми вариантами name.br и name.gz. \\\
+/// Для текстовых файлов без готового name.gz вариант gzip создается при загрузке, если precompress \\\
+
+func loadAsset(fsys fs.FS, name string, modTime time.Time, precompress bool) (*asset, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := fs.Stat(fsys, name); err == nil && !info.ModTime().IsZero() {
+		modTime = info.ModTime()
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	sum := sha256.Sum256(data)
+	a := &asset{
+		name:        name,
+		contentType: contentType,
+		etag:        `"` + hex.EncodeToString(sum[:8]) + `"`,
+		modTime:     modTime,
+		immutable:   fingerprintPattern.MatchString(path.Base(name)),
+		data:        data,
+		variants:    make(map[string][]byte),
+	}
+
+	for _, enc := range encodings {
+		if b, err := fs.ReadFile(fsys, name+enc.ext); err == nil {
+			a.variants[enc.name] = b
+		}
+	}
+	if _, ok := a.variants["gzip"]; !ok && precompress && compressible(contentType) {
+		if b, err := gzipBytes(data); err == nil && len(b) < len(data) {
+			a.variants["gzip"] = b
+		}
+	}
+	return a, nil
+}
+
+/// Функция compressible сообщает, имеет ли смысл сжимать содержимое типа contentType \\\
+
+func compressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.HasPrefix(contentType, "application/javascript") ||
+		strings.HasPrefix(contentType, "application/json") ||
+		strings.HasPrefix(contentType, "application/xml") ||
+		strings.HasPrefix(contentType, "image/svg+xml")
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+/// Функция hidden сообщает, что файл не отдается клиентам: исходники пакета и сжатые варианты других файлов \\\
+
+func hidden(name string) bool {
+	switch path.Ext(name) {
+	case ".go", ".gz", ".br":
+		return true
+	}
+	return false
+}
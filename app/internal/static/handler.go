@@ -0,0 +1,202 @@
+package static
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/internal/response"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"bytes"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	indexPage       = "index.html"
+	notFoundPage    = "404.html"
+	serverErrorPage = "500.html"
+)
+
+/// Структура Handler отдает файлы сайта по всем путям, для которых нет маршрутов API \\\
+
+type Handler struct {
+	log     logger.Logger
+	files   fs.FS
+	dev     bool
+	started time.Time
+	assets  map[string]*asset
+}
+
+/// Функция NewHandler возвращает обработчик встроенных файлов files. \\\
+/// Если задан dir, файлы читаются с диска при каждом запросе и не кэшируются - режим разработки \\\
+
+func NewHandler(log logger.Logger, files fs.FS, dir string) (*Handler, error) {
+	h := &Handler{
+		log:     log,
+		files:   files,
+		started: time.Now().Truncate(time.Second),
+	}
+	if dir != "" {
+		h.files = os.DirFS(dir)
+		h.dev = true
+		log.Infof("serving static files from %s", dir)
+		return h, nil
+	}
+
+	h.assets = make(map[string]*asset)
+	err := fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || hidden(name) {
+			return err
+		}
+		a, err := loadAsset(files, name, h.started, true)
+		if err != nil {
+			return fmt.Errorf("cannot load %s: %v", name, err)
+		}
+		h.assets[name] = a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+/// Функция Register делает обработчик ответом на все пути, не совпавшие с маршрутами router \\\
+
+func (h *Handler) Register(router *httprouter.Router) {
+	router.NotFound = h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.notFound(w, r)
+		return
+	}
+	a, err := h.lookup(assetName(r.URL.Path))
+	if err != nil {
+		h.notFound(w, r)
+		return
+	}
+	h.serve(w, r, a)
+}
+
+/// Функция assetName переводит путь запроса в имя файла: / и /dir/ отдают index.html \\\
+
+func assetName(urlPath string) string {
+	if strings.HasSuffix(urlPath, "/") {
+		urlPath += indexPage
+	}
+	return strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+}
+
+func (h *Handler) lookup(name string) (*asset, error) {
+	if hidden(name) {
+		return nil, fs.ErrNotExist
+	}
+	if h.dev {
+		return loadAsset(h.files, name, h.started, false)
+	}
+	a, ok := h.assets[name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return a, nil
+}
+
+/// Функция serve отдает файл a с учетом If-None-Match, If-Modified-Since и Range, \\\
+/// выбирая сжатый вариант, который принимает клиент \\\
+
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, a *asset) {
+	body, etag := h.negotiate(w, r, a)
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	switch {
+	case h.dev:
+		header.Set("Cache-Control", "no-cache")
+	case a.immutable:
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	default:
+		header.Set("Cache-Control", "public, no-cache")
+	}
+	http.ServeContent(w, r, a.name, a.modTime, bytes.NewReader(body))
+}
+
+/// Функция negotiate выбирает вариант файла по Accept-Encoding и возвращает его тело и ETag \\\
+
+func (h *Handler) negotiate(w http.ResponseWriter, r *http.Request, a *asset) ([]byte, string) {
+	header := w.Header()
+	header.Set("Content-Type", a.contentType)
+	if len(a.variants) == 0 {
+		return a.data, a.etag
+	}
+	header.Add("Vary", "Accept-Encoding")
+	for _, enc := range encodings {
+		if b, ok := a.variants[enc.name]; ok && acceptsEncoding(r, enc.name) {
+			header.Set("Content-Encoding", enc.name)
+			return b, strings.TrimSuffix(a.etag, `"`) + "-" + enc.name + `"`
+		}
+	}
+	return a.data, a.etag
+}
+
+/// Функция notFound отвечает браузерам страницей 404.html, а клиентам API - ошибкой application/problem+json \\\
+
+func (h *Handler) notFound(w http.ResponseWriter, r *http.Request) {
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		if h.page(w, r, http.StatusNotFound, notFoundPage) {
+			return
+		}
+	}
+	response.Error(w, r, apperror.ErrNotFound)
+}
+
+/// Функция ErrorPage отвечает на запрос HTML страницы страницей ошибки err: 404.html для ненайденного, \\\
+/// 500.html для остальных ошибок. Без файла страницы ответом будет ошибка application/problem+json \\\
+
+func (h *Handler) ErrorPage(w http.ResponseWriter, r *http.Request, err error) {
+	status, name := http.StatusInternalServerError, serverErrorPage
+	if apperror.KindOf(err) == apperror.KindNotFound {
+		status, name = http.StatusNotFound, notFoundPage
+	}
+	if !h.page(w, r, status, name) {
+		response.Error(w, r, err)
+		return
+	}
+	log := h.log.FromContext(r.Context()).WithField("status", status)
+	if status >= http.StatusInternalServerError {
+		log.WithError(err).Error("page failed")
+	} else {
+		log.WithError(err).Warn("page not found")
+	}
+}
+
+/// Функция page отвечает страницей name с кодом status и сообщает, нашлась ли страница \\\
+
+func (h *Handler) page(w http.ResponseWriter, r *http.Request, status int, name string) bool {
+	a, err := h.lookup(name)
+	if err != nil {
+		return false
+	}
+	body, _ := h.negotiate(w, r, a)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+	return true
+}
+
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(name, encoding) && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package static
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/internal/response"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var site = fstest.MapFS{
+	"index.html":           {Data: []byte("<h1>" + strings.Repeat("Interior Visualization ", 50) + "</h1>")},
+	"404.html":             {Data: []byte("<h1>Page not found</h1>")},
+	"500.html":             {Data: []byte("<h1>Something went wrong</h1>")},
+	"style.css":            {Data: []byte("body{margin:0}")},
+	"style.css.br":         {Data: []byte("brotli")},
+	"app.0123abcd.js":      {Data: []byte("console.log(1)")},
+	"embed.go":             {Data: []byte("package public")},
+	"portfolio/index.html": {Data: []byte("<h1>Portfolio</h1>")},
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	h, err := NewHandler(logger.GetLogger(), site, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func get(h http.Handler, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServeIndexWithValidators(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := get(h, "/", nil)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "<h1>Interior") {
+		t.Fatalf("GET / = %d %q", rec.Code, rec.Body)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" || rec.Header().Get("Last-Modified") == "" {
+		t.Fatalf("missing validators: %v", rec.Header())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "public, no-cache" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+
+	if rec := get(h, "/index.html", map[string]string{"If-None-Match": etag}); rec.Code != http.StatusNotModified {
+		t.Errorf("conditional GET = %d, want 304", rec.Code)
+	}
+	if rec := get(h, "/portfolio/", nil); rec.Body.String() != "<h1>Portfolio</h1>" {
+		t.Errorf("directory index = %q", rec.Body)
+	}
+}
+
+func TestFingerprintedAssetsAreImmutable(t *testing.T) {
+	rec := get(newTestHandler(t), "/app.0123abcd.js", nil)
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+}
+
+func TestPrecompressedVariants(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := get(h, "/style.css", map[string]string{"Accept-Encoding": "gzip, br"})
+	if rec.Header().Get("Content-Encoding") != "br" || rec.Body.String() != "brotli" {
+		t.Errorf("brotli variant not served: %v %q", rec.Header(), rec.Body)
+	}
+
+	rec = get(h, "/index.html", map[string]string{"Accept-Encoding": "gzip"})
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("gzip variant not served: %v", rec.Header())
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(gz); string(body) != string(site["index.html"].Data) {
+		t.Errorf("gzip variant differs from the file")
+	}
+	if rec.Header().Get("ETag") == get(h, "/index.html", nil).Header().Get("ETag") {
+		t.Error("encoded and identity variants share an ETag")
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := get(h, "/missing.html", map[string]string{"Accept": "text/html,application/xhtml+xml"})
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "<h1>Page not found</h1>" {
+		t.Errorf("browser 404 = %d %q", rec.Code, rec.Body)
+	}
+
+	for _, target := range []string{"/users/unknown", "/embed.go", "/style.css.br"} {
+		rec = get(h, target, map[string]string{"Accept": "application/json"})
+		if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != response.ProblemContentType {
+			t.Errorf("GET %s = %d %s", target, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestErrorPage(t *testing.T) {
+	h := newTestHandler(t)
+	for _, tc := range []struct {
+		err    error
+		status int
+		body   string
+	}{
+		{errors.New("connection refused"), http.StatusInternalServerError, "<h1>Something went wrong</h1>"},
+		{fmt.Errorf("cannot find work: %w", apperror.ErrNotFound), http.StatusNotFound, "<h1>Page not found</h1>"},
+	} {
+		rec := httptest.NewRecorder()
+		h.ErrorPage(rec, httptest.NewRequest(http.MethodGet, "/service.html", nil), tc.err)
+		if rec.Code != tc.status || rec.Body.String() != tc.body || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+			t.Errorf("ErrorPage(%v) = %d %s %q", tc.err, rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+		}
+	}
+
+	without, err := NewHandler(logger.GetLogger(), fstest.MapFS{"index.html": site["index.html"]}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	without.ErrorPage(rec, httptest.NewRequest(http.MethodGet, "/service.html", nil), errors.New("connection refused"))
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != response.ProblemContentType {
+		t.Errorf("ErrorPage without 500.html = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestDirOverride(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(file, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHandler(logger.GetLogger(), site, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := get(h, "/", nil); rec.Body.String() != "v1" || rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("GET / = %q %v", rec.Body, rec.Header())
+	}
+	if err := os.WriteFile(file, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(h, "/", nil); rec.Body.String() != "v2" {
+		t.Errorf("changes on disk are not picked up: %q", rec.Body)
+	}
+}
@@ -3,9 +3,9 @@ package user
 import (
 	"Interior_Visualization_Shop/app/internal/apperror"
 	"Interior_Visualization_Shop/app/internal/handler"
+	"Interior_Visualization_Shop/app/internal/middleware/auth"
 	"Interior_Visualization_Shop/app/internal/response"
 	"Interior_Visualization_Shop/app/pkg/logger"
-	"errors"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
@@ -22,133 +22,140 @@ const (
 type Handler struct {
 	log         logger.Logger
 	userService Service
+	authn       *auth.Authenticator
 }
 
 /// Структура NewHandler возвращает новый экземпляр Handler инициализируя переданные в него аргументы \\\
 
-func NewHandler(log logger.Logger, userService Service) handler.Hand {
+func NewHandler(log logger.Logger, userService Service, authn *auth.Authenticator) handler.Hand {
 	return &Handler{
 		log:         log,
 		userService: userService,
+		authn:       authn,
 	}
 }
 
 /// Структура Register регистрирует новые запросы для пользователей \\\
 
 func (h *Handler) Register(router *httprouter.Router) {
-	router.HandlerFunc(http.MethodGet, userByEmailURL, h.GetUserByEmail)
-	router.HandlerFunc(http.MethodPost, usersURL, h.CreateUser)
-	router.HandlerFunc(http.MethodDelete, userURL, h.DeleteUser)
-	router.HandlerFunc(http.MethodGet, userURL, h.GetUserById)
+	/// Поиск по почте и создание пользователей в обход регистрации доступны только администраторам, \\\
+	/// профиль - его владельцу и администраторам \\\
+	router.HandlerFunc(http.MethodGet, userByEmailURL, h.authn.Role(h.GetUserByEmail, auth.RoleAdmin))
+	router.HandlerFunc(http.MethodPost, usersURL, h.authn.Role(h.CreateUser, auth.RoleAdmin))
+	router.HandlerFunc(http.MethodDelete, userURL, h.authn.Required(h.DeleteUser))
+	router.HandlerFunc(http.MethodGet, userURL, h.authn.Required(h.GetUserById))
+}
+
+/// Функция canAccess разрешает доступ к профилю id его владельцу и администраторам \\\
+
+func canAccess(r *http.Request, id int64) bool {
+	p, ok := auth.FromContext(r.Context())
+	return ok && (p.UserID == id || p.HasRole(auth.RoleAdmin))
 }
 
 /// Функция GetUserById получает пользователя по его id \\\
 
 func (h *Handler) GetUserById(w http.ResponseWriter, r *http.Request) {
-	h.log.Info("HANDLER: GET USER BY ID")
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: GET USER BY ID")
 
 	/// Принимает объект r, представляющий HTTP-запрос, и извлекает параметр ID из URL \\\
 	id, err := handler.ReadIdParam64(r)
 
-	h.log.Printf("Input: %+v\n", &id)
+	log.Printf("Input: %+v\n", &id)
 	if err != nil {
-		response.BadRequest(w, err.Error(), "")
+		response.Error(w, r, err)
+		return
+	}
+	if !canAccess(r, id) {
+		response.Error(w, r, apperror.ErrForbidden)
 		return
 	}
 
 	/// Вызов функции GetById передавая ей id пациента \\\
 	user, err := h.userService.GetById(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, apperror.ErrEmptyString) {
-			response.NotFound(w)
-			return
-		}
-		response.InternalError(w, err.Error(), "")
+		response.Error(w, r, err)
 		return
 	}
-	h.log.Info("GOT USER BY ID")
+	log.Info("GOT USER BY ID")
 	response.JSON(w, http.StatusOK, user)
 }
 
 /// Функция GetUserByEmail получает пользователя по его email \\\
 
 func (h *Handler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
-	h.log.Info("HANDLER: GET USER BY EMAIL")
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: GET USER BY EMAIL")
 
 	/// Принимает объект r, представляющий HTTP-запрос, и извлекает параметр email из URL \\\
 
 	/// Чтение JSON данных из тела входящего запроса r и декодирование их в переменную input \\\
 	email := r.URL.Query().Get("email")
-	h.log.Printf("Input: %+v\n", email)
+	log.Printf("Input: %+v\n", email)
 	if email == "" {
-		response.BadRequest(w, "empty email", "")
+		response.Error(w, r, apperror.Validation(apperror.FieldError{Field: "email", Message: "must not be empty"}))
 		return
 	}
 
 	/// Вызов функции GetByEmail передавая ей email \\\
 	user, err := h.userService.GetByEmail(r.Context(), email)
 	if err != nil {
-		if errors.Is(err, apperror.ErrEmptyString) {
-			response.NotFound(w)
-			return
-		}
-		response.BadRequest(w, err.Error(), "")
+		response.Error(w, r, err)
 		return
 	}
-	h.log.Info("GOT USER BY EMAIL")
+	log.Info("GOT USER BY EMAIL")
 	response.JSON(w, http.StatusOK, user)
 }
 
 /// Функция CreateUser создает пользователя по полученным данным из input \\\
 
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
-	h.log.Info("HANDLER: CREATE USER")
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: CREATE USER")
 
 	var input CreateUserDTO
 
 	/// Чтение JSON данных из тела входящего запроса r и декодирование их в переменную input \\\
 	if err := response.ReadJSON(w, r, &input); err != nil {
-		response.BadRequest(w, err.Error(), apperror.ErrInvalidRequestBody.Error())
+		response.Error(w, r, err)
 		return
 	}
-	h.log.Printf("Input: %+v\n", &input)
+	log.Printf("Input: %+v\n", &input)
 
 	/// Вызов функции Create передавая ей полученные значения и ссылку на структуру input \\\
 	user, err := h.userService.Create(r.Context(), &input)
 	if err != nil {
-		if errors.Is(err, apperror.ErrRepeatedEmail) {
-			response.BadRequest(w, err.Error(), "")
-			return
-		}
-		response.InternalError(w, fmt.Sprintf("cannot create user: %v", err), "")
+		response.Error(w, r, fmt.Errorf("cannot create user: %w", err))
 		return
 	}
-	h.log.Info("USER CREATED")
+	log.Info("USER CREATED")
 	response.JSON(w, http.StatusCreated, user)
 }
 
 /// Функция DeleteUser удаляет пользователя по его id \\\
 
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	h.log.Info("HANDLER: DELETE USER")
+	log := h.log.FromContext(r.Context())
+	log.Info("HANDLER: DELETE USER")
 
 	/// Принимает объект r, представляющий HTTP-запрос, и извлекает параметр ID из URL \\\
 	id, err := handler.ReadIdParam64(r)
 	if err != nil {
-		response.BadRequest(w, err.Error(), "")
+		response.Error(w, r, err)
+		return
+	}
+	log.Printf("Input: %+v\n", id)
+	if !canAccess(r, id) {
+		response.Error(w, r, apperror.ErrForbidden)
 		return
 	}
-	h.log.Printf("Input: %+v\n", id)
 	/// Вызов функции Delete передавая ей полученное значение id \\\
-	err = h.userService.Delete(id)
+	err = h.userService.Delete(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, apperror.ErrEmptyString) {
-			response.NotFound(w)
-			return
-		}
-		response.InternalError(w, err.Error(), "wrong on the server")
+		response.Error(w, r, err)
 		return
 	}
-	h.log.Info("USER DELETED")
+	log.Info("USER DELETED")
 	response.JSON(w, http.StatusOK, "USER DELETED")
 }
@@ -0,0 +1,121 @@
+package user
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/internal/apptest"
+	"Interior_Visualization_Shop/app/internal/middleware/auth"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/token"
+	"context"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+/// Функция newTestRouter возвращает маршрутизатор обработчика пользователей с хранилищем storage \\\
+
+func newTestRouter(storage Storage) *httprouter.Router {
+	log := logger.GetLogger()
+	router := httprouter.New()
+	NewHandler(log, NewService(storage, log), auth.New(log, apptest.AccessTokens(), auth.Options{})).Register(router)
+	return router
+}
+
+func TestHandlerCRUD(t *testing.T) {
+	router, tokens := newTestRouter(NewMemoryStorage()), apptest.AccessTokens()
+	admin := apptest.Bearer(t, tokens, token.User{ID: 100, Roles: []string{auth.RoleUser, auth.RoleAdmin}})
+	owner := apptest.Bearer(t, tokens, token.User{ID: 1, Roles: []string{auth.RoleUser}})
+	stranger := apptest.Bearer(t, tokens, token.User{ID: 2, Roles: []string{auth.RoleUser}})
+	const body = `{"email":"petrovmaksim1992@mail.ru","name":"Maksim","surname":"Petrov","password":"abcdEFG1"}`
+
+	rec := apptest.Serve(router, http.MethodPost, "/users", body, "Authorization", admin)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /users: got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	var created User
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode created user: %v", err)
+	}
+	if created.ID < 1 || created.Email != "petrovmaksim1992@mail.ru" {
+		t.Fatalf("POST /users: got %+v", created)
+	}
+	if strings.Contains(rec.Body.String(), `"password"`) {
+		t.Errorf("POST /users: the response has the password hash: %s", rec.Body)
+	}
+
+	if rec = apptest.Serve(router, http.MethodPost, "/users", body, "Authorization", admin); rec.Code != http.StatusConflict {
+		t.Errorf("POST /users with a repeated email: got status %d, want %d", rec.Code, http.StatusConflict)
+	}
+	rec = apptest.Serve(router, http.MethodPost, "/users", `{"email":"petrov","name":"","surname":"Petrov","password":"short"}`, "Authorization", admin)
+	if rec.Code != http.StatusBadRequest || strings.Count(rec.Body.String(), `"field"`) != 3 {
+		t.Errorf("POST /users with invalid fields: got status %d, want %d with 3 field errors: %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+	if rec = apptest.Serve(router, http.MethodPost, "/users", `{"email":`, "Authorization", admin); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST /users with malformed JSON: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	if rec = apptest.Serve(router, http.MethodGet, "/users/profile/1", "", "Authorization", owner); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `"password"`) {
+		t.Errorf("GET /users/profile/1: got status %d, want %d without the password hash: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if rec = apptest.Serve(router, http.MethodGet, "/users/email?email=petrovmaksim1992@mail.ru", "", "Authorization", admin); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `"password"`) {
+		t.Errorf("GET /users/email: got status %d, want %d without the password hash: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if rec = apptest.Serve(router, http.MethodGet, "/users/email", "", "Authorization", admin); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /users/email without email: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec = apptest.Serve(router, http.MethodGet, "/users/profile/abc", "", "Authorization", owner); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /users/profile/abc: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	if rec = apptest.Serve(router, http.MethodGet, "/users/profile/1", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /users/profile/1 without a token: got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec = apptest.Serve(router, http.MethodGet, "/users/profile/1", "", "Authorization", stranger); rec.Code != http.StatusForbidden {
+		t.Errorf("GET /users/profile/1 as another user: got status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec = apptest.Serve(router, http.MethodDelete, "/users/profile/1", "", "Authorization", stranger); rec.Code != http.StatusForbidden {
+		t.Errorf("DELETE /users/profile/1 as another user: got status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec = apptest.Serve(router, http.MethodGet, "/users/profile/1", "", "Authorization", admin); rec.Code != http.StatusOK {
+		t.Errorf("GET /users/profile/1 as an admin: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec = apptest.Serve(router, http.MethodGet, "/users/email?email=petrovmaksim1992@mail.ru", "", "Authorization", owner); rec.Code != http.StatusForbidden {
+		t.Errorf("GET /users/email as a user: got status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	if rec = apptest.Serve(router, http.MethodDelete, "/users/profile/1", "", "Authorization", owner); rec.Code != http.StatusOK {
+		t.Errorf("DELETE /users/profile/1: got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec = apptest.Serve(router, http.MethodGet, "/users/profile/1", "", "Authorization", owner); rec.Code != http.StatusNotFound {
+		t.Errorf("GET deleted user: got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec = apptest.Serve(router, http.MethodDelete, "/users/profile/1", "", "Authorization", owner); rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE deleted user: got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+/// Структура racingStorage не находит существующих пользователей, как при параллельной регистрации, которая еще не завершена: \\\
+/// повтор адреса может поймать только само хранилище \\\
+
+type racingStorage struct {
+	Storage
+}
+
+func (racingStorage) FindByEmail(ctx context.Context, email string) (*User, error) {
+	return nil, apperror.ErrNotFound
+}
+
+func TestHandlerCreateDuplicateInStorage(t *testing.T) {
+	router := newTestRouter(racingStorage{Storage: NewMemoryStorage()})
+	admin := apptest.Bearer(t, apptest.AccessTokens(), token.User{ID: 100, Roles: []string{auth.RoleAdmin}})
+	const body = `{"email":"petrovmaksim1992@mail.ru","name":"Maksim","surname":"Petrov","password":"abcdEFG1"}`
+
+	if rec := apptest.Serve(router, http.MethodPost, "/users", body, "Authorization", admin); rec.Code != http.StatusCreated {
+		t.Fatalf("POST /users: got status %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	if rec := apptest.Serve(router, http.MethodPost, "/users", body, "Authorization", admin); rec.Code != http.StatusConflict {
+		t.Errorf("POST /users with a repeated email: got status %d, want %d: %s", rec.Code, http.StatusConflict, rec.Body)
+	}
+}
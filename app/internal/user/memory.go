@@ -0,0 +1,108 @@
+package user
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"context"
+	"strings"
+	"sync"
+)
+
+var _ Storage = &MemoryStorage{}
+
+/// Структура MemoryStorage хранящая пользователей в памяти процесса (режим "memory" для демонстраций и тестов) \\\
+
+type MemoryStorage struct {
+	log    logger.Logger
+	mu     sync.RWMutex
+	users  map[int64]User
+	lastID int64
+}
+
+/// Структура NewMemoryStorage возвращает новый пустой экземпляр MemoryStorage \\\
+
+func NewMemoryStorage() Storage {
+	return &MemoryStorage{
+		log:   logger.GetLogger(),
+		users: make(map[int64]User),
+	}
+}
+
+/// Функция Create для сущности MemoryStorage создает запись пользователя в памяти \\\
+
+func (d *MemoryStorage) Create(ctx context.Context, user *User) (*User, error) {
+	d.log.FromContext(ctx).Info("MEMORY: CREATE USER")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	/// Повторяем регистронезависимое ограничение уникальности email из таблицы users \\\
+	for _, u := range d.users {
+		if strings.EqualFold(u.Email, user.Email) {
+			return nil, apperror.ErrRepeatedEmail
+		}
+	}
+
+	d.lastID++
+	user.ID = d.lastID
+	d.users[user.ID] = *user
+	return user, nil
+}
+
+/// Функция FindByEmail для сущности MemoryStorage получает пользователя по адресу электронной почты \\\
+
+func (d *MemoryStorage) FindByEmail(ctx context.Context, email string) (*User, error) {
+	d.log.FromContext(ctx).Info("MEMORY: GET USER BY EMAIL")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, u := range d.users {
+		if strings.EqualFold(u.Email, email) {
+			return &u, nil
+		}
+	}
+	return nil, apperror.ErrNotFound
+}
+
+/// Функция FindById для сущности MemoryStorage получает пользователя по id \\\
+
+func (d *MemoryStorage) FindById(ctx context.Context, id int64) (*User, error) {
+	d.log.FromContext(ctx).Info("MEMORY: GET USER BY ID")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	u, ok := d.users[id]
+	if !ok {
+		return nil, apperror.ErrNotFound
+	}
+	return &u, nil
+}
+
+/// Функция Delete для сущности MemoryStorage удаляет пользователя по id \\\
+
+func (d *MemoryStorage) Delete(ctx context.Context, id int64) error {
+	d.log.FromContext(ctx).Info("MEMORY: DELETE USER")
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.users[id]; !ok {
+		return apperror.ErrNotFound
+	}
+	delete(d.users, id)
+	return nil
+}
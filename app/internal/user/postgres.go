@@ -3,26 +3,37 @@ package user
 import (
 	"Interior_Visualization_Shop/app/internal/apperror"
 	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/metrics"
+	"Interior_Visualization_Shop/app/pkg/tracing"
 	"context"
 	"errors"
 	"fmt"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"time"
 )
 
 var _ Storage = &UserStorage{}
 
+/// Код ошибки PostgreSQL unique_violation и уникальный индекс адресов почты из db.sql \\\
+
+const (
+	uniqueViolation = "23505"
+	emailIndex      = "users_email_lower_idx"
+)
+
 /// Структура UserStorage содержащая поля для работы с БД \\\
 
 type UserStorage struct {
 	log            logger.Logger
-	conn           *pgx.Conn
+	conn           *pgxpool.Pool
 	requestTimeout time.Duration
 }
 
 /// Структура NewStorage возвращает новый экземпляр UserStorage инициализируя переданные в него аргументы \\\
 
-func NewStorage(storage *pgx.Conn, requestTimeout int) Storage {
+func NewStorage(storage *pgxpool.Pool, requestTimeout int) Storage {
 	return &UserStorage{
 		log:            logger.GetLogger(),
 		conn:           storage,
@@ -32,14 +43,17 @@ func NewStorage(storage *pgx.Conn, requestTimeout int) Storage {
 
 /// Функция Create для сущности UserStorage создает записи пользователя в БД \\\
 
-func (d *UserStorage) Create(user *User) (*User, error) {
-	d.log.Info("POSTGRES: CREATE USER")
+func (d *UserStorage) Create(ctx context.Context, user *User) (*User, error) {
+	d.log.FromContext(ctx).Info("POSTGRES: CREATE USER")
+	ctx, span := tracing.StartQuery(ctx, "create_user")
+	defer span.End()
 
-	/// Ограничение времени выполнения запроса \\\
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	/// Ограничение времени выполнения запроса, не превышающее срок контекста вызывающей стороны \\\
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
 	/// Выполнение запроса к БД \\\
+	start := time.Now()
 	row := d.conn.QueryRow(ctx,
 		`INSERT INTO users (email, name, surname, password)
 			 VALUES($1,$2,$3,$4) 
@@ -48,7 +62,14 @@ func (d *UserStorage) Create(user *User) (*User, error) {
 
 	/// Сканирование полученных значений из БД \\\
 	err := row.Scan(&user.ID)
+	metrics.ObserveQuery("create_user", start, err)
+	tracing.RecordError(span, err)
 	if err != nil {
+		/// Проверка в сервисе не защищает от одновременных регистраций, последней проверкой остается уникальный индекс \\\
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == emailIndex {
+			return nil, apperror.Wrap(err, apperror.KindConflict, apperror.ErrRepeatedEmail.Message)
+		}
 		err = fmt.Errorf("failed to execute create user query: %v", err)
 		return nil, err
 	}
@@ -57,22 +78,27 @@ func (d *UserStorage) Create(user *User) (*User, error) {
 
 /// Функция FindByEmail для сущности UserStorage получает записи пациентов из БД по адресу электронной почты \\\
 
-func (d *UserStorage) FindByEmail(email string) (*User, error) {
-	d.log.Info("POSTGRES: GET USER BY EMAIL")
+func (d *UserStorage) FindByEmail(ctx context.Context, email string) (*User, error) {
+	d.log.FromContext(ctx).Info("POSTGRES: GET USER BY EMAIL")
+	ctx, span := tracing.StartQuery(ctx, "find_user_by_email")
+	defer span.End()
 
-	/// Ограничение времени выполнения запроса \\\
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	/// Ограничение времени выполнения запроса, не превышающее срок контекста вызывающей стороны \\\
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
 	/// Выполнение запроса к БД \\\
+	start := time.Now()
 	row := d.conn.QueryRow(ctx,
 		`SELECT * FROM users
-			 WHERE email = $1`, email)
+			 WHERE lower(email) = lower($1)`, email)
 	user := &User{}
 
 	/// Сканирование полученных значений из БД \\\
 	err := row.Scan(
 		&user.ID, &user.Email, &user.Name, &user.Surname, &user.Password)
+	metrics.ObserveQuery("find_user_by_email", start, err)
+	tracing.RecordError(span, err)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.ErrNotFound
@@ -85,14 +111,17 @@ func (d *UserStorage) FindByEmail(email string) (*User, error) {
 
 /// Функция FindById для сущности UserStorage получает записи пользователя из БД по id \\\
 
-func (d *UserStorage) FindById(id int64) (*User, error) {
-	d.log.Info("POSTGRES: GET USER BY ID")
+func (d *UserStorage) FindById(ctx context.Context, id int64) (*User, error) {
+	d.log.FromContext(ctx).Info("POSTGRES: GET USER BY ID")
+	ctx, span := tracing.StartQuery(ctx, "find_user_by_id")
+	defer span.End()
 
-	/// Ограничение времени выполнения запроса \\\
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	/// Ограничение времени выполнения запроса, не превышающее срок контекста вызывающей стороны \\\
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
 	/// Выполнение запроса к БД \\\
+	start := time.Now()
 	row := d.conn.QueryRow(ctx,
 		`SELECT * FROM users
 			 WHERE id = $1`, id)
@@ -101,9 +130,11 @@ func (d *UserStorage) FindById(id int64) (*User, error) {
 	/// Сканирование полученных значений из БД \\\
 	err := row.Scan(
 		&user.ID, &user.Email, &user.Name, &user.Surname, &user.Password)
+	metrics.ObserveQuery("find_user_by_id", start, err)
+	tracing.RecordError(span, err)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, apperror.ErrEmptyString
+			return nil, apperror.ErrNotFound
 		}
 		err = fmt.Errorf("failed to execute find user by id query: %v", err)
 		return nil, err
@@ -113,22 +144,27 @@ func (d *UserStorage) FindById(id int64) (*User, error) {
 
 /// Функция Delete для сущности UserStorage удаляет записи о пользователях из БД \\\
 
-func (d *UserStorage) Delete(id int64) error {
-	d.log.Info("POSTGRES: DELETE USER")
+func (d *UserStorage) Delete(ctx context.Context, id int64) error {
+	d.log.FromContext(ctx).Info("POSTGRES: DELETE USER")
+	ctx, span := tracing.StartQuery(ctx, "delete_user")
+	defer span.End()
 
-	/// Ограничение времени выполнения запроса \\\
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	/// Ограничение времени выполнения запроса, не превышающее срок контекста вызывающей стороны \\\
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
 	/// Выполнение запроса к БД \\\
+	start := time.Now()
 	result, err := d.conn.Exec(ctx,
 		`DELETE FROM users WHERE id = $1`, id)
+	metrics.ObserveQuery("delete_user", start, err)
+	tracing.RecordError(span, err)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %v", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return apperror.ErrEmptyString
+		return apperror.ErrNotFound
 	}
 	return nil
 }
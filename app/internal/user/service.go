@@ -3,6 +3,7 @@ package user
 import (
 	"Interior_Visualization_Shop/app/internal/apperror"
 	"Interior_Visualization_Shop/app/pkg/logger"
+	"Interior_Visualization_Shop/app/pkg/tracing"
 	"context"
 	"errors"
 	"fmt"
@@ -14,7 +15,7 @@ type Service interface {
 	Create(ctx context.Context, user *CreateUserDTO) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetById(ctx context.Context, id int64) (*User, error)
-	Delete(id int64) error
+	Delete(ctx context.Context, id int64) error
 }
 
 /// Структура  service реализизирующая инфтерфейс Service пользователей \\\
@@ -36,10 +37,13 @@ func NewService(storage Storage, log logger.Logger) Service {
 /// Функция Create создает пользователя через интерфейс Service принимая входные данные input \\\
 
 func (s *service) Create(ctx context.Context, input *CreateUserDTO) (*User, error) {
-	s.log.Info("SERVICE: CREATE USER")
+	ctx, span := tracing.Start(ctx, "user.Service.Create")
+	defer span.End()
+	log := s.log.FromContext(ctx)
+	log.Info("SERVICE: CREATE USER")
 
 	/// Проверка на уникальность email \\\
-	checkEmail, err := s.storage.FindByEmail(input.Email)
+	checkEmail, err := s.storage.FindByEmail(ctx, input.Email)
 	if err != nil {
 		if !errors.Is(err, apperror.ErrNotFound) {
 			return nil, err
@@ -60,11 +64,11 @@ func (s *service) Create(ctx context.Context, input *CreateUserDTO) (*User, erro
 	/// Хэширование пароля \\\
 	err = u.HashPassword()
 	if err != nil {
-		return nil, fmt.Errorf("cannot hash password")
+		return nil, fmt.Errorf("cannot hash password: %w", err)
 	}
 
 	/// Вызов функции Create в хранилище пользователей \\\
-	user, err := s.storage.Create(&u)
+	user, err := s.storage.Create(ctx, &u)
 	if err != nil {
 		return nil, err
 	}
@@ -74,15 +78,17 @@ func (s *service) Create(ctx context.Context, input *CreateUserDTO) (*User, erro
 /// Функция GetByEmail осуществялет поиск пользователей через интерфейс Service принимая входные данные email пользователя \\\
 
 func (s *service) GetByEmail(ctx context.Context, email string) (*User, error) {
-	s.log.Info("SERVICE: GET USER BY EMAIL")
+	ctx, span := tracing.Start(ctx, "user.Service.GetByEmail")
+	defer span.End()
+	log := s.log.FromContext(ctx)
+	log.Info("SERVICE: GET USER BY EMAIL")
 
 	/// Вызов функции FindByEmail в хранилище пользователей \\\
-	user, err := s.storage.FindByEmail(email)
+	user, err := s.storage.FindByEmail(ctx, email)
 	if err != nil {
-		if errors.Is(err, apperror.ErrEmptyString) {
-			return nil, err
+		if !errors.Is(err, apperror.ErrNotFound) {
+			log.Warn("cannot find user by email:", err)
 		}
-		s.log.Warn("cannot find user by email:", err)
 		return nil, err
 	}
 	return user, nil
@@ -91,15 +97,17 @@ func (s *service) GetByEmail(ctx context.Context, email string) (*User, error) {
 /// Функция GetById осуществялет поиск пользователей через интерфейс Service принимая входные данные id пользователя \\\
 
 func (s *service) GetById(ctx context.Context, id int64) (*User, error) {
-	s.log.Info("SERVICE: GET USER BY ID")
+	ctx, span := tracing.Start(ctx, "user.Service.GetById")
+	defer span.End()
+	log := s.log.FromContext(ctx)
+	log.Info("SERVICE: GET USER BY ID")
 
 	/// Вызов функции FindById в хранилище пациентов \\\
-	user, err := s.storage.FindById(id)
+	user, err := s.storage.FindById(ctx, id)
 	if err != nil {
-		if errors.Is(err, apperror.ErrEmptyString) {
-			return nil, err
+		if !errors.Is(err, apperror.ErrNotFound) {
+			log.Warn("cannot find user by id:", err)
 		}
-		s.log.Warn("cannot find user by id:", err)
 		return nil, err
 	}
 	return user, nil
@@ -107,13 +115,16 @@ func (s *service) GetById(ctx context.Context, id int64) (*User, error) {
 
 /// Функция Delete удаляет пользователя через интерфейс Service принимая входные данные id \\\
 
-func (s *service) Delete(id int64) error {
-	s.log.Info("SERVICE: DELETE USER")
+func (s *service) Delete(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "user.Service.Delete")
+	defer span.End()
+	log := s.log.FromContext(ctx)
+	log.Info("SERVICE: DELETE USER")
 	/// Вызов функции Delete в хранилище пациентов \\\
-	err := s.storage.Delete(id)
+	err := s.storage.Delete(ctx, id)
 	if err != nil {
-		if !errors.Is(err, apperror.ErrEmptyString) {
-			s.log.Warn("failed to delete user:", err)
+		if !errors.Is(err, apperror.ErrNotFound) {
+			log.Warn("failed to delete user:", err)
 		}
 		return err
 	}
@@ -1,8 +1,10 @@
 package user
 
+import "context"
+
 type Storage interface {
-	Create(user *User) (*User, error)
-	FindByEmail(email string) (*User, error)
-	FindById(id int64) (*User, error)
-	Delete(id int64) error
+	Create(ctx context.Context, user *User) (*User, error)
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindById(ctx context.Context, id int64) (*User, error)
+	Delete(ctx context.Context, id int64) error
 }
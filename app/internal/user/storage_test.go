@@ -0,0 +1,100 @@
+package user
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"testing"
+)
+
+/// Функция testStorage - общий набор проверок, который проходит каждая реализация Storage \\\
+
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+
+	created, err := s.Create(ctx, &User{Email: "conformance@mail.ru", Name: "Maksim", Surname: "Petrov", Password: "hash"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID < 1 {
+		t.Fatalf("Create: got id %d, want a positive id", created.ID)
+	}
+
+	byEmail, err := s.FindByEmail(ctx, "conformance@mail.ru")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	if *byEmail != *created {
+		t.Errorf("FindByEmail: got %+v, want %+v", *byEmail, *created)
+	}
+
+	byID, err := s.FindById(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("FindById: %v", err)
+	}
+	if *byID != *created {
+		t.Errorf("FindById: got %+v, want %+v", *byID, *created)
+	}
+
+	if _, err = s.Create(ctx, &User{Email: "conformance@mail.ru", Name: "Other", Surname: "Other", Password: "hash"}); apperror.KindOf(err) != apperror.KindConflict {
+		t.Errorf("Create with a repeated email: got %v, want a conflict", err)
+	}
+	if _, err = s.Create(ctx, &User{Email: "Conformance@Mail.ru", Name: "Other", Surname: "Other", Password: "hash"}); apperror.KindOf(err) != apperror.KindConflict {
+		t.Errorf("Create with a repeated email in another case: got %v, want a conflict", err)
+	}
+	if byCase, err := s.FindByEmail(ctx, "CONFORMANCE@mail.ru"); err != nil || byCase.ID != created.ID {
+		t.Errorf("FindByEmail in another case: got %+v, %v", byCase, err)
+	}
+
+	if _, err = s.FindByEmail(ctx, "missing@mail.ru"); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("FindByEmail missing: got %v, want %v", err, apperror.ErrNotFound)
+	}
+
+	if err = s.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err = s.FindById(ctx, created.ID); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("FindById after Delete: got %v, want %v", err, apperror.ErrNotFound)
+	}
+	if err = s.Delete(ctx, created.ID); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("Delete twice: got %v, want %v", err, apperror.ErrNotFound)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = s.FindByEmail(canceled, "conformance@mail.ru"); err == nil {
+		t.Error("FindByEmail with a canceled context: got nil error")
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, NewMemoryStorage())
+}
+
+/// Функция TestPostgresStorage прогоняет набор на настоящей базе, если задана TEST_DATABASE_DSN \\\
+
+func TestPostgresStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	cleanup := func() {
+		if _, err := conn.Exec(ctx, `DELETE FROM users WHERE email = 'conformance@mail.ru'`); err != nil {
+			t.Fatalf("cleanup: %v", err)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	testStorage(t, NewStorage(conn, 5))
+}
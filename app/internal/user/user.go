@@ -1,24 +1,51 @@
 package user
 
 import (
+	"Interior_Visualization_Shop/app/internal/normalize"
+	"Interior_Visualization_Shop/app/internal/validate"
+	"Interior_Visualization_Shop/app/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
 )
 
-/// Структура для создания пользователей \\\
+/// Структура для создания пользователей. Хэш пароля в ответы API не попадает \\\
 
 type User struct {
 	ID       int64  `json:"id" example:"1567"`
-	Email    string `json:"email" example:"petrovmaksim1992@mail.ru"`
+	Email    string `json:"email" example:"petrovmaksim1992@mail.ru" log:"email"`
 	Name     string `json:"name" example:"Maksim"`
 	Surname  string `json:"surname" example:"Petrov"`
-	Password string `json:"password"`
+	Password string `json:"-" log:"secret"`
 }
 
 type CreateUserDTO struct {
-	Email    string `json:"email" example:"petrovmaksim1992@mail.ru"`
+	Email    string `json:"email" example:"petrovmaksim1992@mail.ru" log:"email"`
 	Name     string `json:"name" example:"Maksim"`
 	Surname  string `json:"surname" example:"Petrov"`
-	Password string `json:"password" example:"sfdsg"`
+	Password string `json:"password" example:"sfdsg" log:"secret"`
+}
+
+/// Методы String и GoString скрывают пароли и адреса почты при выводе структур в лог \\\
+
+func (u User) String() string            { return logger.Redacted(u) }
+func (u User) GoString() string          { return logger.Redacted(u) }
+func (u CreateUserDTO) String() string   { return logger.Redacted(u) }
+func (u CreateUserDTO) GoString() string { return logger.Redacted(u) }
+
+/// Функция Normalize приводит адрес почты к каноническому виду \\\
+
+func (u *CreateUserDTO) Normalize() {
+	u.Email = normalize.Email(u.Email)
+}
+
+/// Функция Validate проверяет данные нового пользователя \\\
+
+func (u *CreateUserDTO) Validate() error {
+	var v validate.Errors
+	v.Email("email", u.Email)
+	v.Length("name", u.Name, 1, 100)
+	v.Length("surname", u.Surname, 1, 100)
+	v.Password("password", u.Password)
+	return v.Err()
 }
 
 /// Хэширование паролей \\\
@@ -0,0 +1,30 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDTOsDoNotLeakSecrets(t *testing.T) {
+	const (
+		email    = "petrovmaksim1992@mail.ru"
+		password = "abcdEFG"
+	)
+	dtos := []interface{}{
+		User{ID: 1, Email: email, Name: "Maksim", Surname: "Petrov", Password: password},
+		&User{ID: 1, Email: email, Name: "Maksim", Surname: "Petrov", Password: password},
+		CreateUserDTO{Email: email, Name: "Maksim", Surname: "Petrov", Password: password},
+		&CreateUserDTO{Email: email, Name: "Maksim", Surname: "Petrov", Password: password},
+	}
+	for _, dto := range dtos {
+		for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+			out := fmt.Sprintf(verb, dto)
+			for _, secret := range []string{email, password} {
+				if strings.Contains(out, secret) {
+					t.Errorf("fmt.Sprintf(%q, %T) leaks %q: %s", verb, dto, secret, out)
+				}
+			}
+		}
+	}
+}
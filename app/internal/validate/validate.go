@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/// Ограничения на значения полей запросов \\\
+
+const (
+	MaxEmailLength    = 254
+	MinPasswordLength = 8
+	/// bcrypt учитывает только первые 72 байта пароля \\\
+	MaxPasswordBytes = 72
+)
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+/// Интерфейс Validator реализуют DTO, которые проверяют себя после чтения из запроса \\\
+
+type Validator interface {
+	Validate() error
+}
+
+/// Интерфейс Normalizer реализуют DTO, которые приводят свои поля к каноническому виду до проверки \\\
+
+type Normalizer interface {
+	Normalize()
+}
+
+/// Структура Errors собирает ошибки всех полей, чтобы клиент получил их одним ответом \\\
+
+type Errors struct {
+	fields []apperror.FieldError
+}
+
+/// Функция Add добавляет ошибку message для поля field \\\
+
+func (v *Errors) Add(field, message string) {
+	v.fields = append(v.fields, apperror.FieldError{Field: field, Message: message})
+}
+
+/// Функция Err возвращает ошибку проверки со всеми накопленными полями или nil \\\
+
+func (v *Errors) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return apperror.Validation(v.fields...)
+}
+
+/// Функция Required проверяет, что значение не пустое \\\
+
+func (v *Errors) Required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, "must not be empty")
+		return false
+	}
+	return true
+}
+
+/// Функция Length проверяет, что длина значения в символах от min до max \\\
+
+func (v *Errors) Length(field, value string, min, max int) {
+	n := utf8.RuneCountInString(value)
+	switch {
+	case n < min && min == 1:
+		v.Add(field, "must not be empty")
+	case n < min:
+		v.Add(field, "must be at least "+strconv.Itoa(min)+" characters long")
+	case n > max:
+		v.Add(field, "must be at most "+strconv.Itoa(max)+" characters long")
+	}
+}
+
+/// Функция SingleLine проверяет, что в значении нет управляющих символов. Такие поля попадают в заголовки писем, \\\
+/// где перевод строки добавил бы свои заголовки и получателей \\\
+
+func (v *Errors) SingleLine(field, value string) {
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		v.Add(field, "must not contain line breaks or control characters")
+	}
+}
+
+/// Функция Email проверяет синтаксис адреса электронной почты без отображаемого имени \\\
+
+func (v *Errors) Email(field, value string) {
+	if !v.Required(field, value) {
+		return
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value || len(value) > MaxEmailLength || !strings.Contains(value[strings.LastIndex(value, "@"):], ".") {
+		v.Add(field, "must be a valid email address")
+	}
+}
+
+/// Функция Phone проверяет, что номер телефона записан в формате E.164: +79656879175 \\\
+
+func (v *Errors) Phone(field, value string) {
+	if !v.Required(field, value) {
+		return
+	}
+	if !e164Pattern.MatchString(value) {
+		v.Add(field, "must be a phone number in E.164 format, e.g. +79656879175")
+	}
+}
+
+/// Функция Password проверяет длину пароля и что в нем есть буквы и цифры \\\
+
+func (v *Errors) Password(field, value string) {
+	if utf8.RuneCountInString(value) < MinPasswordLength {
+		v.Add(field, "must be at least "+strconv.Itoa(MinPasswordLength)+" characters long")
+		return
+	}
+	if len(value) > MaxPasswordBytes {
+		v.Add(field, "must be at most "+strconv.Itoa(MaxPasswordBytes)+" bytes long")
+		return
+	}
+	var letter, digit bool
+	for _, r := range value {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	if !letter || !digit {
+		v.Add(field, "must contain both letters and digits")
+	}
+}
@@ -0,0 +1,60 @@
+package validate
+
+import (
+	"Interior_Visualization_Shop/app/internal/apperror"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(v *Errors)
+		ok    bool
+	}{
+		{"email", func(v *Errors) { v.Email("email", "petrovmaksim1992@mail.ru") }, true},
+		{"email with name", func(v *Errors) { v.Email("email", "Maksim <petrov@mail.ru>") }, false},
+		{"email without domain", func(v *Errors) { v.Email("email", "petrov@mail") }, false},
+		{"email without at", func(v *Errors) { v.Email("email", "petrov.mail.ru") }, false},
+		{"empty email", func(v *Errors) { v.Email("email", " ") }, false},
+		{"long email", func(v *Errors) { v.Email("email", strings.Repeat("a", 250)+"@mail.ru") }, false},
+		{"phone", func(v *Errors) { v.Phone("phone", "+79656879175") }, true},
+		{"phone without plus", func(v *Errors) { v.Phone("phone", "89656879175") }, false},
+		{"phone with spaces", func(v *Errors) { v.Phone("phone", "+7 965 687 91 75") }, false},
+		{"phone too long", func(v *Errors) { v.Phone("phone", "+1234567890123456") }, false},
+		{"password", func(v *Errors) { v.Password("password", "abcdEFG1") }, true},
+		{"short password", func(v *Errors) { v.Password("password", "a1") }, false},
+		{"password without digits", func(v *Errors) { v.Password("password", "abcdefgh") }, false},
+		{"password without letters", func(v *Errors) { v.Password("password", "12345678") }, false},
+		{"password over bcrypt limit", func(v *Errors) { v.Password("password", strings.Repeat("a1", 37)) }, false},
+		{"length", func(v *Errors) { v.Length("name", "Максим", 1, 6) }, true},
+		{"too long", func(v *Errors) { v.Length("name", "Максимка", 1, 6) }, false},
+		{"empty", func(v *Errors) { v.Length("name", "", 1, 6) }, false},
+		{"single line", func(v *Errors) { v.SingleLine("subject", "Дизайн кухни") }, true},
+		{"line break", func(v *Errors) { v.SingleLine("subject", "Hi\r\nBcc: victim@mail.ru") }, false},
+		{"control character", func(v *Errors) { v.SingleLine("subject", "Hi\x00") }, false},
+	}
+	for _, tc := range tests {
+		var v Errors
+		tc.check(&v)
+		if err := v.Err(); (err == nil) != tc.ok {
+			t.Errorf("%s: got error %v, want ok=%v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func TestErrorsReportsAllFields(t *testing.T) {
+	var v Errors
+	v.Email("email", "bad")
+	v.Phone("phone", "bad")
+	v.Password("password", "bad")
+
+	var appErr *apperror.AppError
+	if err := v.Err(); !errors.As(err, &appErr) || appErr.Kind != apperror.KindValidation {
+		t.Fatalf("Err: got %v, want a validation error", err)
+	}
+	if len(appErr.Fields) != 3 {
+		t.Errorf("Fields: got %+v, want 3 fields", appErr.Fields)
+	}
+}
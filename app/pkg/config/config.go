@@ -1,53 +1,307 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
+	"io/fs"
 	"log"
+	"reflect"
+	"strings"
 	"sync"
 )
 
+/// Типы хранилищ данных приложения \\\
+
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+/// Алгоритмы подписи токенов доступа \\\
+
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+/// Значения SameSite для cookie сессии браузера \\\
+
+const (
+	SameSiteStrict = "strict"
+	SameSiteLax    = "lax"
+	SameSiteNone   = "none"
+)
+
+/// Режимы запуска приложения. Режим задает значения по умолчанию для уровня логов, \\\
+/// источника файлов сайта, разрешенных источников CORS и открытия браузера \\\
+
+const (
+	ModeDev  = "dev"
+	ModeProd = "prod"
+	ModeTest = "test"
+)
+
+/// Структура modeDefaults - значения, которые режим подставляет в незаданные поля конфигурации \\\
+
+type modeDefaults struct {
+	logLevel       string
+	staticDir      string
+	allowedOrigins []string
+	openBrowser    bool
+}
+
+var modes = map[string]modeDefaults{
+	ModeDev: {
+		logLevel:       "debug",
+		staticDir:      "public",
+		allowedOrigins: []string{"http://localhost:63342", "http://localhost:3001"},
+		openBrowser:    true,
+	},
+	ModeProd: {
+		logLevel: "info",
+	},
+	ModeTest: {
+		logLevel: "warn",
+	},
+}
+
 /// Конфигурация приложения \\\
 
 type Config struct {
+	App struct {
+		Mode string `yaml:"mode" env:"APP_MODE" env-default:"prod"`
+		/// OpenBrowser не читается из файла, его задает режим: браузер открывается только в dev \\\
+		OpenBrowser bool `yaml:"-"`
+	} `yaml:"app"`
+	Logger struct {
+		Level      string `yaml:"level" env:"LOG_LEVEL"`
+		Format     string `yaml:"format" env:"LOG_FORMAT" env-default:"text"`
+		File       string `yaml:"file" env:"LOG_FILE" env-default:"logs/all.log"`
+		MaxSize    int    `yaml:"max_size" env:"LOG_MAX_SIZE" env-default:"100"`
+		MaxAge     int    `yaml:"max_age" env:"LOG_MAX_AGE" env-default:"28"`
+		MaxBackups int    `yaml:"max_backups" env:"LOG_MAX_BACKUPS" env-default:"10"`
+		Compress   bool   `yaml:"compress" env:"LOG_COMPRESS" env-default:"true"`
+	} `yaml:"logger"`
+	Tracing struct {
+		Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+		Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4318"`
+		Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE" env-default:"true"`
+		SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+		ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"interior-visualization-shop"`
+	} `yaml:"tracing"`
+	Metrics struct {
+		/// Addr - отдельный адрес для /metrics, недоступный с публичного порта. Пустой адрес выключает метрики \\\
+		Addr string `yaml:"addr" env:"METRICS_ADDR" env-default:"localhost:9100"`
+	} `yaml:"metrics"`
+	Storage struct {
+		Type string `yaml:"type" env:"STORAGE_TYPE" env-default:"postgres"`
+	} `yaml:"storage"`
 	HTTP struct {
-		Host         string `yaml:"host" env:"HTTP-HOST"`
-		Port         string `yaml:"port" env:"HTTP-PORT"`
-		ReadTimeout  int    `yaml:"read_timeout" env:"HTTP-READ-TIMEOUT"`
-		WriteTimeout int    `yaml:"write_timeout" env:"HTTP-WRITE-TIMEOUT"`
+		Host                  string `yaml:"host" env:"HTTP_HOST" env-default:"localhost"`
+		Port                  string `yaml:"port" env:"HTTP_PORT" env-default:"3001"`
+		ReadTimeout           int    `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" env-default:"30"`
+		WriteTimeout          int    `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" env-default:"30"`
+		MaxBodyBytes          int64  `yaml:"max_body_bytes" env:"HTTP_MAX_BODY_BYTES" env-default:"10485760"`
+		Compress              bool   `yaml:"compress" env:"HTTP_COMPRESS" env-default:"true"`
+		ContentSecurityPolicy string `yaml:"content_security_policy" env:"HTTP_CONTENT_SECURITY_POLICY"`
+		HTTP2                 bool   `yaml:"http2" env:"HTTP_HTTP2" env-default:"true"`
+		H2C                   bool   `yaml:"h2c" env:"HTTP_H2C" env-default:"false"`
 	} `yaml:"http"`
+	TLS struct {
+		Enabled               bool   `yaml:"enabled" env:"TLS_ENABLED" env-default:"false"`
+		CertFile              string `yaml:"cert_file" env:"TLS_CERT_FILE"`
+		KeyFile               string `yaml:"key_file" env:"TLS_KEY_FILE"`
+		ReloadInterval        int    `yaml:"reload_interval" env:"TLS_RELOAD_INTERVAL" env-default:"30"`
+		RedirectAddr          string `yaml:"redirect_addr" env:"TLS_REDIRECT_ADDR"`
+		HSTSMaxAge            int    `yaml:"hsts_max_age" env:"TLS_HSTS_MAX_AGE" env-default:"31536000"`
+		HSTSIncludeSubdomains bool   `yaml:"hsts_include_subdomains" env:"TLS_HSTS_INCLUDE_SUBDOMAINS" env-default:"false"`
+	} `yaml:"tls"`
+	CORS struct {
+		AllowedOrigins   []string `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" env-separator:","`
+		AllowedMethods   []string `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS" env-separator:"," env-default:"GET,POST,DELETE,OPTIONS"`
+		AllowedHeaders   []string `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" env-separator:"," env-default:"Authorization,Content-Type,X-Request-ID,X-CSRF-Token,traceparent,tracestate"`
+		ExposedHeaders   []string `yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS" env-separator:"," env-default:"X-Request-ID,Retry-After"`
+		AllowCredentials bool     `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" env-default:"true"`
+		MaxAge           int      `yaml:"max_age" env:"CORS_MAX_AGE" env-default:"600"`
+	} `yaml:"cors"`
 	PostgreSQL struct {
-		DSN               string `env:"DATABASE_DSN" env-required:"true"`
-		RequestTimeout    int    `yaml:"request_timeout" env-default:"5"`
-		ConnectionTimeout int    `yaml:"connection_timeout" env-default:"10"`
-		ShutdownTimeout   int    `yaml:"shutdown_timeout" env-default:"5"`
-	} `yaml:"postgresql" env-required:"true"`
+		DSN               Secret `yaml:"dsn" env:"DATABASE_DSN"`
+		RequestTimeout    int    `yaml:"request_timeout" env:"POSTGRES_REQUEST_TIMEOUT" env-default:"5"`
+		ConnectionTimeout int    `yaml:"connection_timeout" env:"POSTGRES_CONNECTION_TIMEOUT" env-default:"10"`
+		ShutdownTimeout   int    `yaml:"shutdown_timeout" env:"POSTGRES_SHUTDOWN_TIMEOUT" env-default:"5"`
+		ConnectAttempts   int    `yaml:"connect_attempts" env:"POSTGRES_CONNECT_ATTEMPTS" env-default:"5"`
+		/// MaxConns - размер пула соединений, общего для всех обработчиков \\\
+		MaxConns int32 `yaml:"max_conns" env:"POSTGRES_MAX_CONNS" env-default:"10"`
+	} `yaml:"postgresql"`
+	Site struct {
+		Name        string `yaml:"name" env:"SITE_NAME" env-default:"VJ&M Interior Visualization"`
+		Description string `yaml:"description" env:"SITE_DESCRIPTION" env-default:"Freelance interior visualization: 3D models, animation and photorealistic renders"`
+		BaseURL     string `yaml:"base_url" env:"SITE_BASE_URL" env-default:"http://localhost:3001"`
+		Phone       string `yaml:"phone" env:"SITE_PHONE" env-default:"+7 952 020 81 66"`
+		Email       string `yaml:"email" env:"SITE_EMAIL" env-default:"vasileva.julia02@gmail.com"`
+		Image       string `yaml:"image" env:"SITE_IMAGE" env-default:"9.jpg"`
+		Locale      string `yaml:"locale" env:"SITE_LOCALE" env-default:"en_US"`
+		Currency    string `yaml:"currency" env:"SITE_CURRENCY" env-default:"RUB"`
+	} `yaml:"site"`
+	Static struct {
+		Dir string `yaml:"dir" env:"STATIC_DIR"`
+	} `yaml:"static"`
+	Blob struct {
+		Dir string `yaml:"dir" env:"BLOB_DIR" env-default:"appealdocuments"`
+	} `yaml:"blob"`
 	JWT struct {
-		AccessExpirationMinutes int16  `yaml:"access_expiration_minutes"`
-		RefreshExpirationDays   int16  `yaml:"refresh_expiration_days"`
-		AccessTokenSecretKey    string `yaml:"access_token_secret_key"`
-		RefreshTokenSecretKey   string `yaml:"refresh_token_secret_key"`
+		/// Algorithm - подпись токенов доступа: HS256 общим секретом или RS256 и EdDSA ключом из PEM, \\\
+		/// открытую часть которого другие сервисы берут из /.well-known/jwks.json \\\
+		Algorithm               string `yaml:"algorithm" env:"JWT_ALGORITHM" env-default:"HS256"`
+		Issuer                  string `yaml:"issuer" env:"JWT_ISSUER" env-default:"interior-visualization-shop"`
+		Audience                string `yaml:"audience" env:"JWT_AUDIENCE" env-default:"interior-visualization-shop"`
+		AccessTokenPrivateKey   Secret `yaml:"access_token_private_key" env:"JWT_ACCESS_TOKEN_PRIVATE_KEY"`
+		AccessExpirationMinutes int16  `yaml:"access_expiration_minutes" env:"JWT_ACCESS_EXPIRATION_MINUTES" env-default:"10"`
+		RefreshExpirationDays   int16  `yaml:"refresh_expiration_days" env:"JWT_REFRESH_EXPIRATION_DAYS" env-default:"15"`
+		AccessTokenSecretKey    Secret `yaml:"access_token_secret_key" env:"JWT_ACCESS_TOKEN_SECRET_KEY"`
+		RefreshTokenSecretKey   Secret `yaml:"refresh_token_secret_key" env:"JWT_REFRESH_TOKEN_SECRET_KEY"`
+		/// Идентификаторы kid действующих ключей и прежние ключи вида "kid:secret,kid:secret" для их смены без разлогинивания пользователей \\\
+		AccessTokenKeyID         string `yaml:"access_token_key_id" env:"JWT_ACCESS_TOKEN_KEY_ID" env-default:"1"`
+		RefreshTokenKeyID        string `yaml:"refresh_token_key_id" env:"JWT_REFRESH_TOKEN_KEY_ID" env-default:"1"`
+		PreviousAccessTokenKeys  Secret `yaml:"previous_access_token_keys" env:"JWT_PREVIOUS_ACCESS_TOKEN_KEYS"`
+		PreviousRefreshTokenKeys Secret `yaml:"previous_refresh_token_keys" env:"JWT_PREVIOUS_REFRESH_TOKEN_KEYS"`
 	} `yaml:"jwt"`
+	Auth struct {
+		/// Пользователи с этими адресами почты получают роль admin \\\
+		AdminEmails []string `yaml:"admin_emails" env:"AUTH_ADMIN_EMAILS" env-separator:","`
+		/// Cookies включает режим браузера: вход с ?session=cookie кладет токены в HttpOnly cookie, \\\
+		/// а запросы с ними, меняющие данные, должны нести CSRF токен в заголовке CSRFHeader. \\\
+		/// Сессию продлевает POST /sign_in/refresh, cookie RefreshCookie браузер отправляет только ему \\\
+		Cookies       bool   `yaml:"cookies" env:"AUTH_COOKIES" env-default:"false"`
+		AccessCookie  string `yaml:"access_cookie" env:"AUTH_ACCESS_COOKIE" env-default:"access_token"`
+		RefreshCookie string `yaml:"refresh_cookie" env:"AUTH_REFRESH_COOKIE" env-default:"refresh_token"`
+		CSRFCookie    string `yaml:"csrf_cookie" env:"AUTH_CSRF_COOKIE" env-default:"csrf_token"`
+		CSRFHeader    string `yaml:"csrf_header" env:"AUTH_CSRF_HEADER" env-default:"X-CSRF-Token"`
+		CookieSecure  bool   `yaml:"cookie_secure" env:"AUTH_COOKIE_SECURE" env-default:"true"`
+		SameSite      string `yaml:"same_site" env:"AUTH_SAME_SITE" env-default:"strict"`
+	} `yaml:"auth"`
+	Normalize struct {
+		DefaultRegion  string `yaml:"default_region" env:"NORMALIZE_DEFAULT_REGION" env-default:"RU"`
+		LowercaseEmail bool   `yaml:"lowercase_email" env:"NORMALIZE_LOWERCASE_EMAIL" env-default:"true"`
+	} `yaml:"normalize"`
+	Verification struct {
+		CodeLength   int    `yaml:"code_length" env:"VERIFICATION_CODE_LENGTH" env-default:"6"`
+		CodeAlphabet string `yaml:"code_alphabet" env:"VERIFICATION_CODE_ALPHABET" env-default:"0123456789"`
+		TTL          int    `yaml:"ttl" env:"VERIFICATION_TTL" env-default:"900"`
+		MaxAttempts  int    `yaml:"max_attempts" env:"VERIFICATION_MAX_ATTEMPTS" env-default:"5"`
+		MagicLink    bool   `yaml:"magic_link" env:"VERIFICATION_MAGIC_LINK" env-default:"true"`
+		BaseURL      string `yaml:"base_url" env:"VERIFICATION_BASE_URL" env-default:"http://localhost:3001"`
+	} `yaml:"verification"`
+	RateLimit struct {
+		Enabled        bool   `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+		Storage        string `yaml:"storage" env:"RATE_LIMIT_STORAGE" env-default:"memory"`
+		Window         int    `yaml:"window" env:"RATE_LIMIT_WINDOW" env-default:"60"`
+		IPLimit        int    `yaml:"ip_limit" env:"RATE_LIMIT_IP" env-default:"20"`
+		EmailLimit     int    `yaml:"email_limit" env:"RATE_LIMIT_EMAIL" env-default:"5"`
+		MaxFailures    int    `yaml:"max_failures" env:"RATE_LIMIT_MAX_FAILURES" env-default:"5"`
+		FailureWindow  int    `yaml:"failure_window" env:"RATE_LIMIT_FAILURE_WINDOW" env-default:"86400"`
+		LockoutBase    int    `yaml:"lockout_base" env:"RATE_LIMIT_LOCKOUT_BASE" env-default:"60"`
+		LockoutMax     int    `yaml:"lockout_max" env:"RATE_LIMIT_LOCKOUT_MAX" env-default:"3600"`
+		TrustForwarded bool   `yaml:"trust_forwarded" env:"RATE_LIMIT_TRUST_FORWARDED" env-default:"false"`
+	} `yaml:"rate_limit"`
 	MAIL struct {
-		MailAddress  string `env:"MAIL_ADD" env-required:"true""`
-		MailPassword string `env:"MAIL_PAS" env-required:"true"`
-	}
+		MailAddress  string `yaml:"address" env:"MAIL_ADD"`
+		MailPassword Secret `yaml:"password" env:"MAIL_PAS"`
+	} `yaml:"mail"`
 }
 
-// / Функция для получения конфигурации приложения из файла config.yml \\\
-var cfg Config
+var (
+	cfg  Config
+	once sync.Once
+)
 
-func GetConfig(configPath string, dotenvPath string) *Config {
-	var once sync.Once
-
-	if err := godotenv.Load(dotenvPath); err != nil {
-		log.Fatalf("could not load .env file: %v", err)
-	}
+/// Функция GetConfig читает и проверяет конфигурацию один раз за время работы процесса. \\\
+/// При ошибке в конфигурации приложение завершается со списком всех найденных проблем \\\
 
+func GetConfig(configPath string, dotenvPath string) *Config {
 	once.Do(func() {
-		if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-			log.Fatalf("config file does not exist: %v", err)
+		c, err := Read(configPath, dotenvPath)
+		if err == nil {
+			err = c.Validate()
 		}
+		if err != nil {
+			log.Fatalf("invalid config:\n%v", err)
+		}
+		cfg = *c
 	})
 	return &cfg
 }
+
+/// Функция Read читает переменные из dotenvPath, если файл есть, затем config.yml, переменные окружения и файлы секретов NAME_FILE. \\\
+/// Переменные окружения важнее файла, незаданные поля заполняются значениями по умолчанию и режима. Проверку делает Validate \\\
+
+func Read(configPath string, dotenvPath string) (*Config, error) {
+	if err := godotenv.Load(dotenvPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("cannot load %s: %v", dotenvPath, err)
+	}
+
+	c := &Config{}
+	if err := cleanenv.ReadConfig(configPath, c); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", configPath, err)
+	}
+	if err := readSecretFiles(reflect.ValueOf(c).Elem()); err != nil {
+		return nil, err
+	}
+	if err := c.applyMode(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+/// Функция applyMode заполняет поля, не заданные в файле и окружении, значениями режима App.Mode \\\
+
+func (c *Config) applyMode() error {
+	c.App.Mode = strings.ToLower(strings.TrimSpace(c.App.Mode))
+	defaults, ok := modes[c.App.Mode]
+	if !ok {
+		return fmt.Errorf("unknown app mode %q, want %s, %s or %s", c.App.Mode, ModeDev, ModeProd, ModeTest)
+	}
+	if c.Logger.Level == "" {
+		c.Logger.Level = defaults.logLevel
+	}
+	if c.Static.Dir == "" {
+		c.Static.Dir = defaults.staticDir
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		c.CORS.AllowedOrigins = defaults.allowedOrigins
+	}
+	c.App.OpenBrowser = defaults.openBrowser
+	return nil
+}
+
+/// Функция Summary возвращает основные действующие настройки для строки лога при запуске, без секретов \\\
+
+func (c *Config) Summary() map[string]interface{} {
+	static := "embedded"
+	if c.Static.Dir != "" {
+		static = c.Static.Dir
+	}
+	origins := "same origin only"
+	if len(c.CORS.AllowedOrigins) > 0 {
+		origins = strings.Join(c.CORS.AllowedOrigins, ",")
+	}
+	return map[string]interface{}{
+		"mode":         c.App.Mode,
+		"address":      c.HTTP.Host + ":" + c.HTTP.Port,
+		"tls":          c.TLS.Enabled,
+		"http2":        c.HTTP.HTTP2,
+		"storage":      c.Storage.Type,
+		"log_level":    c.Logger.Level,
+		"static":       static,
+		"cors_origins": origins,
+		"open_browser": c.App.OpenBrowser,
+		"tracing":      c.Tracing.Exporter,
+		"metrics":      c.Metrics.Addr,
+		"rate_limit":   c.RateLimit.Enabled,
+		"auth_cookies": c.Auth.Cookies,
+		"site":         c.Site.BaseURL,
+	}
+}
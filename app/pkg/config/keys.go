@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+/// Структура SigningKey - ключ подписи JWT и его идентификатор kid из заголовка токена \\\
+
+type SigningKey struct {
+	ID     string
+	Secret Secret
+}
+
+/// Функция AccessTokenKeys возвращает ключи токенов доступа: первым действующий, которым подписываются новые токены, \\\
+/// за ним прежние ключи, которые еще принимаются при проверке, пока не истекут выданные ими токены. \\\
+/// Для RS256 и EdDSA ключи - это PEM, для прежних ключей достаточно открытой части \\\
+
+func (c *Config) AccessTokenKeys() ([]SigningKey, error) {
+	current := c.JWT.AccessTokenSecretKey
+	if c.JWT.Algorithm != JWTAlgorithmHS256 {
+		current = c.JWT.AccessTokenPrivateKey
+	}
+	return signingKeys(c.JWT.AccessTokenKeyID, current, c.JWT.PreviousAccessTokenKeys)
+}
+
+/// Функция RefreshTokenKeys возвращает ключи токенов обновления в том же порядке, что и AccessTokenKeys \\\
+
+func (c *Config) RefreshTokenKeys() ([]SigningKey, error) {
+	return signingKeys(c.JWT.RefreshTokenKeyID, c.JWT.RefreshTokenSecretKey, c.JWT.PreviousRefreshTokenKeys)
+}
+
+/// Функция signingKeys разбирает список прежних ключей вида "kid:secret,kid:secret". В PEM нет запятых, поэтому разделитель тот же \\\
+
+func signingKeys(id string, current Secret, previous Secret) ([]SigningKey, error) {
+	keys := []SigningKey{{ID: id, Secret: current}}
+	seen := map[string]bool{id: true}
+	for _, entry := range strings.Split(previous.Value(), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, secret, ok := strings.Cut(entry, ":")
+		kid = strings.TrimSpace(kid)
+		if !ok || kid == "" || secret == "" {
+			return nil, fmt.Errorf("previous key %d is not in the kid:secret form", len(keys))
+		}
+		if seen[kid] {
+			return nil, fmt.Errorf("key id %q is used more than once", kid)
+		}
+		seen[kid] = true
+		keys = append(keys, SigningKey{ID: kid, Secret: Secret(secret)})
+	}
+	return keys, nil
+}
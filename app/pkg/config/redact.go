@@ -0,0 +1,38 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"reflect"
+)
+
+/// Значение, которым заменяются секреты при выводе конфигурации \\\
+
+const redactedValue = "[REDACTED]"
+
+/// Функция Redacted возвращает копию конфигурации, в которой непустые поля типа Secret заменены на [REDACTED] \\\
+
+func (c Config) Redacted() Config {
+	redact(reflect.ValueOf(&c).Elem())
+	return c
+}
+
+func redact(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct:
+			redact(field)
+		case field.Type() == secretType && field.String() != "":
+			field.SetString(redactedValue)
+		}
+	}
+}
+
+/// Функция YAML возвращает действующую конфигурацию в формате config.yml, с redact - без секретов \\\
+
+func (c Config) YAML(redact bool) ([]byte, error) {
+	if redact {
+		c = c.Redacted()
+	}
+	return yaml.Marshal(c)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+/// Тип Secret - строка с секретом. При выводе через fmt и JSON вместо значения печатается [REDACTED], \\\
+/// поэтому секрет не попадет в лог вместе со структурой Config. Само значение возвращает Value \\\
+
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+func (s Secret) GoString() string {
+	return fmt.Sprintf("%q", s.String())
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+/// Функция readSecretFiles читает секреты из файлов: если задана переменная NAME_FILE, где NAME - env тег поля, \\\
+/// значение берется из указанного файла. Так подключаются Docker и Kubernetes secrets. Заданы обе переменные - ошибка \\\
+
+func readSecretFiles(v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := readSecretFiles(field); err != nil {
+				return err
+			}
+			continue
+		}
+		env := v.Type().Field(i).Tag.Get("env")
+		if field.Type() != secretType || env == "" {
+			continue
+		}
+		path := os.Getenv(env + "_FILE")
+		if path == "" {
+			continue
+		}
+		if _, ok := os.LookupEnv(env); ok {
+			return fmt.Errorf("both %s and %s_FILE are set, keep one of them", env, env)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("cannot read %s_FILE: %v", env, err)
+		}
+		field.SetString(strings.TrimRight(string(data), "\r\n"))
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/// Минимальная длина секретов JWT в байтах: HS256 ключ короче 32 байт подбирается перебором \\\
+
+const MinSecretLength = 32
+
+/// Структура problems собирает ошибки конфигурации, чтобы показать их все сразу, а не по одной за запуск \\\
+
+type problems []error
+
+func (p *problems) add(field, format string, args ...interface{}) {
+	*p = append(*p, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+func (p *problems) oneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	p.add(field, "%q is not one of %s", value, strings.Join(allowed, ", "))
+}
+
+func (p *problems) positive(field string, value int) {
+	if value <= 0 {
+		p.add(field, "must be greater than zero, got %d", value)
+	}
+}
+
+func (p *problems) required(field, value, env string) {
+	if strings.TrimSpace(value) == "" {
+		p.add(field, "is required, set it in the config file or the %s variable", env)
+	}
+}
+
+func (p *problems) secret(field string, value Secret, env string) {
+	switch {
+	case value == "":
+		p.add(field, "is required, set the %s or %s_FILE variable", env, env)
+	case len(value) < MinSecretLength:
+		p.add(field, "must be at least %d bytes long, got %d", MinSecretLength, len(value))
+	}
+}
+
+/// Функция signingKeys проверяет прежние ключи JWT: формат списка, уникальность kid и длину каждого секрета \\\
+
+func (p *problems) signingKeys(field string, keys func() ([]SigningKey, error)) {
+	list, err := keys()
+	if err != nil {
+		p.add(field, "%v", err)
+		return
+	}
+	for _, k := range list[1:] {
+		if len(k.Secret) < MinSecretLength {
+			p.add(field, "key %q must be at least %d bytes long, got %d", k.ID, MinSecretLength, len(k.Secret))
+		}
+	}
+}
+
+func (p *problems) absoluteURL(field, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.add(field, "%q is not an absolute http(s) URL", value)
+	}
+}
+
+/// Функция Validate проверяет конфигурацию и возвращает все найденные ошибки одной ошибкой, по одной на строку \\\
+
+func (c *Config) Validate() error {
+	var p problems
+
+	p.oneOf("app.mode", c.App.Mode, ModeDev, ModeProd, ModeTest)
+	p.oneOf("logger.level", c.Logger.Level, "trace", "debug", "info", "warn", "warning", "error", "fatal", "panic")
+	p.oneOf("logger.format", c.Logger.Format, "text", "json")
+	p.oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		p.add("tracing.sample_ratio", "must be from 0 to 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	if port, err := strconv.Atoi(c.HTTP.Port); err != nil || port < 1 || port > 65535 {
+		p.add("http.port", "%q is not a port number from 1 to 65535", c.HTTP.Port)
+	}
+	p.positive("http.read_timeout", c.HTTP.ReadTimeout)
+	p.positive("http.write_timeout", c.HTTP.WriteTimeout)
+	if c.HTTP.MaxBodyBytes < 0 {
+		p.add("http.max_body_bytes", "must not be negative, 0 disables the limit")
+	}
+
+	if c.TLS.Enabled {
+		p.required("tls.cert_file", c.TLS.CertFile, "TLS_CERT_FILE")
+		p.required("tls.key_file", c.TLS.KeyFile, "TLS_KEY_FILE")
+		p.positive("tls.reload_interval", c.TLS.ReloadInterval)
+	}
+
+	p.oneOf("storage.type", c.Storage.Type, StoragePostgres, StorageMemory)
+	p.oneOf("rate_limit.storage", c.RateLimit.Storage, StoragePostgres, StorageMemory)
+	if c.Storage.Type == StoragePostgres || c.RateLimit.Storage == StoragePostgres {
+		p.required("postgresql.dsn", c.PostgreSQL.DSN.Value(), "DATABASE_DSN")
+	}
+	p.positive("postgresql.request_timeout", c.PostgreSQL.RequestTimeout)
+	p.positive("postgresql.connection_timeout", c.PostgreSQL.ConnectionTimeout)
+	p.positive("postgresql.shutdown_timeout", c.PostgreSQL.ShutdownTimeout)
+	p.positive("postgresql.connect_attempts", c.PostgreSQL.ConnectAttempts)
+
+	p.positive("jwt.access_expiration_minutes", int(c.JWT.AccessExpirationMinutes))
+	p.positive("jwt.refresh_expiration_days", int(c.JWT.RefreshExpirationDays))
+	p.oneOf("jwt.algorithm", c.JWT.Algorithm, JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA)
+	p.required("jwt.issuer", c.JWT.Issuer, "JWT_ISSUER")
+	p.required("jwt.audience", c.JWT.Audience, "JWT_AUDIENCE")
+	if c.JWT.Algorithm == JWTAlgorithmHS256 {
+		p.secret("jwt.access_token_secret_key", c.JWT.AccessTokenSecretKey, "JWT_ACCESS_TOKEN_SECRET_KEY")
+		if c.JWT.AccessTokenSecretKey != "" && c.JWT.AccessTokenSecretKey == c.JWT.RefreshTokenSecretKey {
+			p.add("jwt.refresh_token_secret_key", "must differ from the access token secret")
+		}
+	} else {
+		p.required("jwt.access_token_private_key", c.JWT.AccessTokenPrivateKey.Value(), "JWT_ACCESS_TOKEN_PRIVATE_KEY_FILE")
+	}
+	p.secret("jwt.refresh_token_secret_key", c.JWT.RefreshTokenSecretKey, "JWT_REFRESH_TOKEN_SECRET_KEY")
+	p.required("jwt.access_token_key_id", c.JWT.AccessTokenKeyID, "JWT_ACCESS_TOKEN_KEY_ID")
+	p.required("jwt.refresh_token_key_id", c.JWT.RefreshTokenKeyID, "JWT_REFRESH_TOKEN_KEY_ID")
+	if c.JWT.Algorithm == JWTAlgorithmHS256 {
+		p.signingKeys("jwt.previous_access_token_keys", c.AccessTokenKeys)
+	} else if _, err := c.AccessTokenKeys(); err != nil {
+		p.add("jwt.previous_access_token_keys", "%v", err)
+	}
+	p.signingKeys("jwt.previous_refresh_token_keys", c.RefreshTokenKeys)
+
+	if c.Auth.Cookies {
+		p.required("auth.access_cookie", c.Auth.AccessCookie, "AUTH_ACCESS_COOKIE")
+		p.required("auth.refresh_cookie", c.Auth.RefreshCookie, "AUTH_REFRESH_COOKIE")
+		p.required("auth.csrf_cookie", c.Auth.CSRFCookie, "AUTH_CSRF_COOKIE")
+		p.required("auth.csrf_header", c.Auth.CSRFHeader, "AUTH_CSRF_HEADER")
+		p.oneOf("auth.same_site", c.Auth.SameSite, SameSiteStrict, SameSiteLax, SameSiteNone)
+		/// Браузеры отбрасывают cookie SameSite=None без Secure \\\
+		if c.Auth.SameSite == SameSiteNone && !c.Auth.CookieSecure {
+			p.add("auth.same_site", "none requires auth.cookie_secure")
+		}
+	}
+
+	p.required("mail.address", c.MAIL.MailAddress, "MAIL_ADD")
+	p.required("mail.password", c.MAIL.MailPassword.Value(), "MAIL_PAS")
+
+	p.required("blob.dir", c.Blob.Dir, "BLOB_DIR")
+	p.absoluteURL("site.base_url", c.Site.BaseURL)
+	p.absoluteURL("verification.base_url", c.Verification.BaseURL)
+	if c.Verification.CodeLength < 4 {
+		p.add("verification.code_length", "must be at least 4, got %d", c.Verification.CodeLength)
+	}
+	if len([]rune(c.Verification.CodeAlphabet)) < 2 {
+		p.add("verification.code_alphabet", "must contain at least 2 symbols")
+	}
+	p.positive("verification.ttl", c.Verification.TTL)
+	p.positive("verification.max_attempts", c.Verification.MaxAttempts)
+
+	if c.RateLimit.Enabled {
+		p.positive("rate_limit.window", c.RateLimit.Window)
+		p.positive("rate_limit.ip_limit", c.RateLimit.IPLimit)
+		p.positive("rate_limit.email_limit", c.RateLimit.EmailLimit)
+		p.positive("rate_limit.max_failures", c.RateLimit.MaxFailures)
+	}
+
+	return errors.Join(p...)
+}
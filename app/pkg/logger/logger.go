@@ -1,12 +1,22 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 	"io"
 	"os"
 	"path"
 	"runtime"
+	"sync"
+)
+
+/// Форматы вывода логов \\\
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
 )
 
 type writerHook struct {
@@ -43,36 +53,145 @@ func (l *Logger) GetLoggerWithField(k string, v interface{}) *Logger {
 	return &Logger{l.WithField(k, v)}
 }
 
-func init() {
-	l := logrus.New()
-	l.SetReportCaller(true)
-	l.Formatter = &logrus.TextFormatter{
-		CallerPrettyfier: func(frame *runtime.Frame) (function string, file string) {
-			filename := path.Base(frame.File)
-			return fmt.Sprintf("%s()", frame.Function), fmt.Sprintf("%s:%d", filename, frame.Line)
-		},
-		DisableColors: false,
-		FullTimestamp: true,
+/// Ключ контекста, под которым хранится логгер запроса \\\
+
+type ctxKey struct{}
+
+/// Функция ContextWithLogger возвращает контекст, к которому привязан логгер l запроса \\\
+
+func ContextWithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+/// Функция FromContext возвращает логгер запроса из ctx, а если его нет - исходный логгер l \\\
+
+func (l Logger) FromContext(ctx context.Context) Logger {
+	if reqLog, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return reqLog
 	}
+	return l
+}
+
+/// Функция callerPrettyfier сокращает путь к файлу, из которого была вызвана запись лога \\\
+
+func callerPrettyfier(frame *runtime.Frame) (function string, file string) {
+	filename := path.Base(frame.File)
+	return fmt.Sprintf("%s()", frame.Function), fmt.Sprintf("%s:%d", filename, frame.Line)
+}
+
+/// Настройки логгера: уровень, формат и файл с ротацией по размеру и возрасту \\\
+
+type Options struct {
+	Level      string
+	Format     string
+	File       string
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+	Compress   bool
+}
+
+/// Файл логов с ротацией, пока логгер не настроен записи идут только в stdout \\\
+
+var (
+	fileMu     sync.Mutex
+	fileWriter *lumberjack.Logger
+)
+
+/// Функция Configure применяет настройки opts: уровень, формат и файл вывода логов \\\
 
-	err := os.MkdirAll("logs", 0644)
+func Configure(opts Options) error {
+	level, err := logrus.ParseLevel(opts.Level)
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	allFile, err := os.OpenFile("logs/all.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	formatter, err := newFormatter(opts.Format)
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	writers := []io.Writer{os.Stdout}
+	var newFile *lumberjack.Logger
+	if opts.File != "" {
+		/// lumberjack сам создает каталог и ротирует файл при превышении MaxSize мегабайт \\\
+		newFile = &lumberjack.Logger{
+			Filename:   opts.File,
+			MaxSize:    opts.MaxSize,
+			MaxAge:     opts.MaxAge,
+			MaxBackups: opts.MaxBackups,
+			Compress:   opts.Compress,
+		}
+		writers = append(writers, newFile)
+	}
+
+	hooks := make(logrus.LevelHooks)
+	hooks.Add(&redactHook{})
+	hooks.Add(&writerHook{
+		Writer:    writers,
+		LogLevels: logrus.AllLevels,
+	})
+
+	e.Logger.SetFormatter(formatter)
+	e.Logger.SetLevel(level)
+	e.Logger.ReplaceHooks(hooks)
+
+	fileMu.Lock()
+	oldFile := fileWriter
+	fileWriter = newFile
+	fileMu.Unlock()
+	if oldFile != nil {
+		return oldFile.Close()
 	}
+	return nil
+}
+
+/// Функция Reopen закрывает файл логов, следующая запись откроет его заново. \\\
+/// Вызывается по SIGHUP после того, как внешний logrotate переместил файл \\\
 
+func Reopen() error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	if fileWriter == nil {
+		return nil
+	}
+	return fileWriter.Close()
+}
+
+/// Функция newFormatter возвращает форматтер логов: text или json \\\
+
+func newFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case FormatText, "":
+		return &logrus.TextFormatter{
+			CallerPrettyfier: callerPrettyfier,
+			DisableColors:    false,
+			FullTimestamp:    true,
+		}, nil
+	case FormatJSON:
+		return &logrus.JSONFormatter{
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+/// До вызова Configure логгер пишет в stdout в текстовом формате с уровнем info \\\
+
+func init() {
+	l := logrus.New()
+	l.SetReportCaller(true)
+	l.Formatter, _ = newFormatter(FormatText)
 	l.SetOutput(io.Discard)
 
+	/// Маскирование секретов должно выполняться раньше записи в stdout и файл \\\
+	l.AddHook(&redactHook{})
 	l.AddHook(&writerHook{
-		Writer:    []io.Writer{allFile, os.Stdout},
+		Writer:    []io.Writer{os.Stdout},
 		LogLevels: logrus.AllLevels,
 	})
 
-	l.SetLevel(logrus.TraceLevel)
+	l.SetLevel(logrus.InfoLevel)
 
 	e = logrus.NewEntry(l)
 }
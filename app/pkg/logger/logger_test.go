@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigureAndReopen(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "logs", "all.log")
+	if err := Configure(Options{Level: "warn", Format: FormatJSON, File: file, MaxSize: 1}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := Configure(Options{Level: "info", Format: FormatText}); err != nil {
+			t.Errorf("restore logger: %v", err)
+		}
+	})
+
+	log := GetLogger()
+	log.Info("filtered by level")
+	log.Warn("first")
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	if strings.Contains(string(data), "filtered by level") {
+		t.Errorf("info entry written at warn level: %s", data)
+	}
+	var entry map[string]interface{}
+	if err = json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v: %s", err, data)
+	}
+	if entry["msg"] != "first" || entry["level"] != "warning" {
+		t.Errorf("unexpected log entry: %v", entry)
+	}
+
+	/// Имитация внешней ротации: файл перемещен, после Reopen записи идут в новый файл \\\
+	if err = os.Rename(file, file+".1"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if err = Reopen(); err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	log.Warn("second")
+
+	data, err = os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("log file was not reopened: %v", err)
+	}
+	if !strings.Contains(string(data), "second") || strings.Contains(string(data), "first") {
+		t.Errorf("reopened log file: %s", data)
+	}
+}
+
+func TestConfigureRejectsInvalidOptions(t *testing.T) {
+	if err := Configure(Options{Level: "loud", Format: FormatText}); err == nil {
+		t.Error("Configure with an unknown level: got nil error")
+	}
+	if err := Configure(Options{Level: "info", Format: "xml"}); err == nil {
+		t.Error("Configure with an unknown format: got nil error")
+	}
+}
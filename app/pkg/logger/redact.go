@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+/// Значения тега log, которыми помечаются чувствительные поля структур \\\
+/// log:"secret" - значение полностью скрывается (пароли, коды, токены) \\\
+/// log:"email"  - адрес электронной почты маскируется, остается первая буква и домен \\\
+
+const (
+	tagSecret = "secret"
+	tagEmail  = "email"
+
+	redacted = "[REDACTED]"
+)
+
+/// Ключи полей лога, значения которых всегда скрываются \\\
+
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"code":          true,
+	"confirm_code":  true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"jwt":           true,
+	"secret":        true,
+	"authorization": true,
+	"cookie":        true,
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	secretPattern = regexp.MustCompile(`(?i)\b((?:password|(?:confirm_)?code|(?:access_|refresh_)?token|secret)["']?\s*[:=]\s*["']?)([^\s"',}&]+)`)
+)
+
+/// Функция MaskEmail оставляет от адреса первую букву и домен: p***@mail.ru \\\
+
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+/// Функция RedactString скрывает адреса почты и значения вида password=..., code: ... в произвольной строке \\\
+
+func RedactString(s string) string {
+	s = secretPattern.ReplaceAllString(s, "${1}"+redacted)
+	return emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+}
+
+/// Функция Redacted печатает структуру в формате %+v, учитывая теги log у ее полей. \\\
+/// Используется в методах String и GoString DTO, чтобы ни один глагол fmt не вывел секреты \\\
+
+func Redacted(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "<nil>"
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return RedactString(fmt.Sprint(rv.Interface()))
+	}
+
+	var b strings.Builder
+	b.WriteString("{")
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if b.Len() > 1 {
+			b.WriteString(" ")
+		}
+		b.WriteString(field.Name)
+		b.WriteString(":")
+		b.WriteString(redactField(field, rv.Field(i)))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+/// Функция redactField возвращает значение поля с учетом его тега log \\\
+
+func redactField(field reflect.StructField, value reflect.Value) string {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "<nil>"
+		}
+		value = value.Elem()
+	}
+	switch field.Tag.Get("log") {
+	case tagSecret:
+		return redacted
+	case tagEmail:
+		return MaskEmail(fmt.Sprint(value.Interface()))
+	}
+	if value.Kind() == reflect.Struct {
+		return Redacted(value.Interface())
+	}
+	return RedactString(fmt.Sprint(value.Interface()))
+}
+
+/// Структура redactHook маскирует чувствительные данные в записи лога до того, как ее запишет writerHook \\\
+
+type redactHook struct{}
+
+func (hook *redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook *redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = RedactString(entry.Message)
+	for k, v := range entry.Data {
+		if sensitiveKeys[strings.ToLower(k)] {
+			entry.Data[k] = redacted
+			continue
+		}
+		switch val := v.(type) {
+		case string:
+			entry.Data[k] = RedactString(val)
+		case error:
+			entry.Data[k] = RedactString(val.Error())
+		case fmt.Stringer:
+			entry.Data[k] = RedactString(val.String())
+		}
+	}
+	return nil
+}
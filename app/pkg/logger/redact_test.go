@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"strings"
+	"testing"
+)
+
+type credentials struct {
+	Email    string `log:"email"`
+	Name     string
+	Password string  `log:"secret"`
+	Token    *string `log:"secret"`
+	Nested   struct {
+		Code string `log:"secret"`
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	cases := map[string]string{
+		"petrovmaksim1992@mail.ru": "p***@mail.ru",
+		"a@b.c":                    "a***@b.c",
+		"not-an-email":             redacted,
+		"@mail.ru":                 redacted,
+	}
+	for in, want := range cases {
+		if got := MaskEmail(in); got != want {
+			t.Errorf("MaskEmail(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	cases := map[string]string{
+		"Input: petrovmaksim1992@mail.ru":             "Input: p***@mail.ru",
+		"Input: &{Email:x@mail.ru Password:adgsjdgs}": "Input: &{Email:x***@mail.ru Password:[REDACTED]}",
+		`{"password":"abcdEFG","name":"Maksim"}`:      `{"password":"[REDACTED]","name":"Maksim"}`,
+		"code=1234&access_token=eyJhbGci":             "code=[REDACTED]&access_token=[REDACTED]",
+		"status_code=200":                             "status_code=200",
+	}
+	for in, want := range cases {
+		if got := RedactString(in); got != want {
+			t.Errorf("RedactString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	token := "eyJhbGciOiJIUzI1NiJ9"
+	c := credentials{Email: "petrovmaksim1992@mail.ru", Name: "Maksim", Password: "abcdEFG", Token: &token}
+	c.Nested.Code = "4821"
+
+	got := Redacted(&c)
+	want := "{Email:p***@mail.ru Name:Maksim Password:[REDACTED] Token:[REDACTED] Nested:{Code:[REDACTED]}}"
+	if got != want {
+		t.Errorf("Redacted = %q, want %q", got, want)
+	}
+	if got = Redacted((*credentials)(nil)); got != "<nil>" {
+		t.Errorf("Redacted(nil) = %q", got)
+	}
+}
+
+func TestRedactHook(t *testing.T) {
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.AddHook(&redactHook{})
+	l.AddHook(&writerHook{Writer: []io.Writer{&buf}, LogLevels: logrus.AllLevels})
+
+	l.WithFields(logrus.Fields{
+		"password":    "abcdEFG",
+		"Token":       "eyJhbGciOiJIUzI1NiJ9",
+		"email":       "petrovmaksim1992@mail.ru",
+		"status_code": 200,
+	}).WithError(errors.New("user petrovmaksim1992@mail.ru not found")).
+		Infof("Input: %+v", struct{ Email, Password string }{"petrovmaksim1992@mail.ru", "abcdEFG"})
+
+	out := buf.String()
+	for _, secret := range []string{"abcdEFG", "eyJhbGciOiJIUzI1NiJ9", "petrovmaksim1992"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log line leaks %q: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "status_code=200") {
+		t.Errorf("log line lost a non-sensitive field: %s", out)
+	}
+}
+
+func ExampleRedacted() {
+	fmt.Println(Redacted(struct {
+		Email    string `log:"email"`
+		Password string `log:"secret"`
+	}{"petrovmaksim1992@mail.ru", "abcdEFG"}))
+	// Output: {Email:p***@mail.ru Password:[REDACTED]}
+}
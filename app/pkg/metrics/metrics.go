@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "interior_shop"
+
+/// Шаги воронки регистрации \\\
+
+const (
+	StepCodeSent     = "code_sent"
+	StepCodeVerified = "code_verified"
+	StepCodeRejected = "code_rejected"
+	StepRegistered   = "registered"
+)
+
+/// Виды писем, отправляемых пользователям \\\
+
+const (
+	MailConfirmation = "confirmation"
+	MailAppeal       = "appeal"
+)
+
+/// Реестр метрик приложения, публикуется обработчиком Handler на /metrics \\\
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of database queries by operation and result.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "result"})
+
+	mailSentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mail_sent_total",
+		Help:      "Number of emails sent by kind and result.",
+	}, []string{"kind", "result"})
+
+	registrationFunnel = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registration_funnel_total",
+		Help:      "Number of users that reached each registration step.",
+	}, []string{"step"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		httpRequestsTotal,
+		dbQueryDuration,
+		mailSentTotal,
+		registrationFunnel,
+	)
+}
+
+/// Функция Handler возвращает обработчик, отдающий метрики в формате Prometheus \\\
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+/// Функция ObserveHTTP учитывает завершенный HTTP запрос \\\
+
+func ObserveHTTP(method, route string, status int, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+	httpRequestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+}
+
+/// Функция ObserveQuery учитывает запрос к БД, начатый в start. Отсутствие строк ошибкой не считается \\\
+
+func ObserveQuery(operation string, start time.Time, err error) {
+	result := "ok"
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		result = "error"
+	}
+	dbQueryDuration.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
+
+/// Функция ObserveMail учитывает отправку письма вида kind \\\
+
+func ObserveMail(kind string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	mailSentTotal.WithLabelValues(kind, result).Inc()
+}
+
+/// Функция RegistrationStep учитывает переход пользователя на шаг step воронки регистрации \\\
+
+func RegistrationStep(step string) {
+	registrationFunnel.WithLabelValues(step).Inc()
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+/// Функция scrape возвращает реестр в текстовом формате Prometheus, как его видит сборщик метрик \\\
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scrape: got status %d", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func TestObserve(t *testing.T) {
+	ObserveHTTP(http.MethodGet, "/users/profile/:id", http.StatusNotFound, 20*time.Millisecond)
+	ObserveQuery("test_found", time.Now(), nil)
+	ObserveQuery("test_missing", time.Now(), pgx.ErrNoRows)
+	ObserveQuery("test_failed", time.Now(), errors.New("connection reset"))
+	ObserveMail(MailAppeal, errors.New("smtp is down"))
+	RegistrationStep(StepCodeSent)
+
+	body := scrape(t)
+	for _, want := range []string{
+		`interior_shop_http_requests_total{method="GET",route="/users/profile/:id",status="404"} 1`,
+		`interior_shop_http_request_duration_seconds_count{method="GET",route="/users/profile/:id"} 1`,
+		`interior_shop_db_query_duration_seconds_count{operation="test_found",result="ok"} 1`,
+		`interior_shop_db_query_duration_seconds_count{operation="test_missing",result="ok"} 1`,
+		`interior_shop_db_query_duration_seconds_count{operation="test_failed",result="error"} 1`,
+		`interior_shop_mail_sent_total{kind="appeal",result="failure"} 1`,
+		`interior_shop_registration_funnel_total{step="code_sent"} 1`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
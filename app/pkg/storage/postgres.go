@@ -2,29 +2,68 @@ package postgres
 
 import (
 	"Interior_Visualization_Shop/app/pkg/config"
+	"Interior_Visualization_Shop/app/pkg/logger"
 	"context"
 	"fmt"
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"time"
 )
 
-func ConnectDB(cfg config.Config) (*pgx.Conn, error) {
+/// Максимальная пауза между попытками подключения к БД \\\
 
-	pgxConfig, err := pgx.ParseConfig(cfg.PostgreSQL.DSN)
+const maxConnectBackoff = 30 * time.Second
+
+/// Функция ConnectDB открывает пул соединений с БД, повторяя попытки с экспоненциальной паузой. \\\
+/// Одно соединение *pgx.Conn не допускает параллельных запросов, поэтому все обработчики работают через пул. \\\
+/// Возвращает ошибку, если ни одна из ConnectAttempts попыток не прошла проверку ping \\\
+
+func ConnectDB(cfg config.Config) (*pgxpool.Pool, error) {
+	log := logger.GetLogger()
+
+	poolConfig, err := pgxpool.ParseConfig(cfg.PostgreSQL.DSN.Value())
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse database config from dsn %v", err)
 	}
+	if cfg.PostgreSQL.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.PostgreSQL.MaxConns
+	}
+
+	attempts := cfg.PostgreSQL.ConnectAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		pool, err := connect(poolConfig, time.Duration(cfg.PostgreSQL.ConnectionTimeout)*time.Second)
+		if err == nil {
+			return pool, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		log.WithError(err).Warnf("database is not available (attempt %d of %d), retrying in %s", attempt, attempts, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
+
+/// Функция connect выполняет одну попытку подключения и проверяет соединение через ping \\\
 
-	dbTimeout, dbCancel := context.WithTimeout(context.Background(), time.Duration(cfg.PostgreSQL.ConnectionTimeout)*time.Second)
+func connect(poolConfig *pgxpool.Config, timeout time.Duration) (*pgxpool.Pool, error) {
+	dbTimeout, dbCancel := context.WithTimeout(context.Background(), timeout)
 	defer dbCancel()
 
-	dbConn, err := pgx.ConnectConfig(dbTimeout, pgxConfig)
+	pool, err := pgxpool.ConnectConfig(dbTimeout, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to database: %v", err)
 	}
 
-	if err = dbConn.Ping(dbTimeout); err != nil {
-		fmt.Errorf("cannot ping database: %v", err)
+	if err = pool.Ping(dbTimeout); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("cannot ping database: %v", err)
 	}
-	return dbConn, nil
+	return pool, nil
 }
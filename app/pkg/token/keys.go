@@ -0,0 +1,218 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+)
+
+/// Алгоритмы подписи \\\
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+/// Минимальная длина ключа RSA в битах \\\
+
+const minRSABits = 2048
+
+/// Структура Key - ключ подписи и его идентификатор kid. Для HS256 Material - общий секрет, \\\
+/// для RS256 и EdDSA - PEM закрытого ключа или, для ключа только для проверки, открытого \\\
+
+type Key struct {
+	ID       string
+	Material []byte
+}
+
+/// Структура JWK - открытый ключ в формате RFC 7517 \\\
+
+type JWK struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Alg     string `json:"alg"`
+	N       string `json:"n,omitempty"`
+	E       string `json:"e,omitempty"`
+	Curve   string `json:"crv,omitempty"`
+	X       string `json:"x,omitempty"`
+}
+
+/// Структура JWKS - набор открытых ключей для /.well-known/jwks.json \\\
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+/// Структура KeySet хранит ключ подписи новых токенов и ключи проверки по kid, включая прежние ключи \\\
+
+type KeySet struct {
+	method  jwt.SigningMethod
+	current string
+	signing interface{}
+	verify  map[string]interface{}
+	public  JWKS
+}
+
+/// Функция NewKeySet разбирает ключи для алгоритма algorithm. Первый ключ подписывает новые токены, \\\
+/// остальные только проверяют уже выданные \\\
+
+func NewKeySet(algorithm string, keys []Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys")
+	}
+	ks := &KeySet{
+		current: keys[0].ID,
+		verify:  make(map[string]interface{}, len(keys)),
+		public:  JWKS{Keys: []JWK{}},
+	}
+	switch algorithm {
+	case HS256:
+		ks.method = jwt.SigningMethodHS256
+		for _, k := range keys {
+			if len(k.Material) == 0 {
+				return nil, fmt.Errorf("key %q: empty secret", k.ID)
+			}
+			ks.verify[k.ID] = k.Material
+		}
+		ks.signing = keys[0].Material
+		return ks, nil
+	case RS256:
+		ks.method = jwt.SigningMethodRS256
+	case EdDSA:
+		ks.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+
+	for i, k := range keys {
+		private, public, err := parseKeyPEM(k.Material)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", k.ID, err)
+		}
+		if i == 0 {
+			if private == nil {
+				return nil, fmt.Errorf("key %q: a private key is needed to sign tokens", k.ID)
+			}
+			ks.signing = private
+		}
+		jwk, err := newJWK(k.ID, algorithm, public)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", k.ID, err)
+		}
+		ks.verify[k.ID] = public
+		ks.public.Keys = append(ks.public.Keys, jwk)
+	}
+	return ks, nil
+}
+
+/// Функция Algorithm возвращает алгоритм подписи набора \\\
+
+func (ks *KeySet) Algorithm() string {
+	return ks.method.Alg()
+}
+
+/// Функция JWKS возвращает открытые ключи набора. Для HS256 набор пуст: общий секрет не публикуется \\\
+
+func (ks *KeySet) JWKS() JWKS {
+	return ks.public
+}
+
+/// Функция sign подписывает claims действующим ключом и записывает его идентификатор в заголовок kid \\\
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(ks.method, claims)
+	t.Header["kid"] = ks.current
+	return t.SignedString(ks.signing)
+}
+
+/// Функция keyFunc выбирает ключ проверки по заголовку kid. Токены без kid, выданные до смены ключей, проверяются действующим ключом. \\\
+/// Алгоритм токена сверяется с набором еще до выбора ключа, иначе открытый ключ RSA можно было бы выдать за секрет HS256 \\\
+
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	if t.Method.Alg() != ks.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing algorithm %q", t.Method.Alg())
+	}
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = ks.current
+	}
+	key, ok := ks.verify[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+/// Функция parseKeyPEM разбирает закрытый ключ PKCS#8 или PKCS#1 либо открытый ключ PKIX. Для открытого ключа private равен nil \\\
+
+func parseKeyPEM(data []byte) (private crypto.Signer, public crypto.PublicKey, err error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no PEM block found")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported private key %T", key)
+		}
+		return signer, signer.Public(), nil
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, key.Public(), nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		return nil, key, err
+	}
+	return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+/// Функция newJWK описывает открытый ключ в формате JWK и проверяет, что он подходит алгоритму \\\
+
+func newJWK(kid, algorithm string, public crypto.PublicKey) (JWK, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		if algorithm != RS256 {
+			break
+		}
+		if key.N.BitLen() < minRSABits {
+			return JWK{}, fmt.Errorf("RSA key must be at least %d bits, got %d", minRSABits, key.N.BitLen())
+		}
+		return JWK{
+			KeyType: "RSA",
+			KeyID:   kid,
+			Use:     "sig",
+			Alg:     algorithm,
+			N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		if algorithm != EdDSA {
+			break
+		}
+		return JWK{
+			KeyType: "OKP",
+			KeyID:   kid,
+			Use:     "sig",
+			Alg:     algorithm,
+			Curve:   "Ed25519",
+			X:       base64.RawURLEncoding.EncodeToString(key),
+		}, nil
+	}
+	return JWK{}, fmt.Errorf("%T cannot be used with %s", public, algorithm)
+}
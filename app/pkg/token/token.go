@@ -0,0 +1,145 @@
+package token
+
+import (
+	"Interior_Visualization_Shop/app/pkg/logger"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"strconv"
+	"time"
+)
+
+/// Ошибки проверки токена. Подробная причина добавляется к ним через %w и нужна только для логов \\\
+
+var (
+	ErrInvalid = errors.New("invalid token")
+	ErrExpired = errors.New("token expired")
+)
+
+/// Структура User - данные пользователя в токене \\\
+
+type User struct {
+	ID      int64    `json:"id"`
+	Email   string   `json:"email,omitempty" log:"email"`
+	Name    string   `json:"name,omitempty"`
+	Surname string   `json:"surname,omitempty"`
+	Roles   []string `json:"roles,omitempty"`
+}
+
+/// Методы String и GoString скрывают адрес почты при выводе пользователя в лог \\\
+
+func (u User) String() string   { return logger.Redacted(u) }
+func (u User) GoString() string { return logger.Redacted(u) }
+
+/// Структура Claims - утверждения токена: стандартные iss, aud, sub, iat, exp, jti, пользователь и сессия входа sid. sub - это User.ID. \\\
+/// Случайный jti делает различными даже токены одной сессии, выпущенные в одну секунду \\\
+
+type Claims struct {
+	jwt.RegisteredClaims
+	User      User   `json:"user"`
+	SessionID string `json:"sid,omitempty"`
+}
+
+/// Интерфейс Issuer выпускает подписанные токены для пользователя в сессии sessionID \\\
+
+type Issuer interface {
+	Issue(user User, sessionID string) (string, error)
+}
+
+/// Интерфейс Verifier проверяет токен и возвращает его утверждения. Ошибка оборачивает ErrExpired или ErrInvalid \\\
+
+type Verifier interface {
+	Verify(token string) (*Claims, error)
+}
+
+/// Настройки токенов одного вида: издатель, адресат и время жизни. Now подменяется в тестах \\\
+
+type Options struct {
+	Issuer   string
+	Audience string
+	TTL      time.Duration
+	Now      func() time.Time
+}
+
+/// Структура JWT выпускает и проверяет токены одного вида набором ключей keys \\\
+
+type JWT struct {
+	keys   *KeySet
+	opts   Options
+	parser *jwt.Parser
+}
+
+/// Функция New возвращает JWT, реализующий Issuer и Verifier \\\
+
+func New(keys *KeySet, opts Options) *JWT {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &JWT{
+		keys: keys,
+		opts: opts,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{keys.Algorithm()}),
+			jwt.WithIssuer(opts.Issuer),
+			jwt.WithAudience(opts.Audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+			jwt.WithTimeFunc(opts.Now),
+		),
+	}
+}
+
+/// Функция Issue выпускает токен для user со сроком действия Options.TTL. Токены одного входа получают общий sessionID \\\
+
+func (j *JWT) Issue(user User, sessionID string) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("cannot generate token id: %w", err)
+	}
+	now := j.opts.Now()
+	return j.keys.sign(&Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        base64.RawURLEncoding.EncodeToString(id),
+			Issuer:    j.opts.Issuer,
+			Audience:  jwt.ClaimStrings{j.opts.Audience},
+			Subject:   strconv.FormatInt(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.opts.TTL)),
+		},
+		User:      user,
+		SessionID: sessionID,
+	})
+}
+
+/// Функция Verify проверяет подпись, алгоритм, kid, срок действия, издателя, адресата и пользователя токена \\\
+
+func (j *JWT) Verify(raw string) (*Claims, error) {
+	claims := &Claims{}
+	if _, err := j.parser.ParseWithClaims(raw, claims, j.keys.keyFunc); err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("%w: %v", ErrExpired, err)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if claims.IssuedAt == nil {
+		return nil, fmt.Errorf("%w: token has no iat", ErrInvalid)
+	}
+	if claims.Subject == "" || claims.Subject != strconv.FormatInt(claims.User.ID, 10) {
+		return nil, fmt.Errorf("%w: subject %q does not match the user", ErrInvalid, claims.Subject)
+	}
+	return claims, nil
+}
+
+/// Функция TTL возвращает время жизни выпускаемых токенов \\\
+
+func (j *JWT) TTL() time.Duration {
+	return j.opts.TTL
+}
+
+/// Функция JWKS возвращает открытые ключи, которыми проверяются токены \\\
+
+func (j *JWT) JWKS() JWKS {
+	return j.keys.JWKS()
+}
@@ -0,0 +1,324 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testUser = User{ID: 7, Email: "petrovmaksim1992@mail.ru", Name: "Maksim", Surname: "Petrov", Roles: []string{"user"}}
+	secret   = []byte("0123456789abcdef0123456789abcdef")
+	rsaKey   = mustRSAKey(2048)
+	edKey    = mustEdKey()
+)
+
+func mustRSAKey(bits int) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func mustEdKey() ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func privatePEM(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func publicPEM(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+/// Структура clock - часы, которые тесты истечения переводят вручную \\\
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newJWT(t *testing.T, algorithm string, c *clock, keys ...Key) *JWT {
+	t.Helper()
+	ks, err := NewKeySet(algorithm, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(ks, Options{Issuer: "shop.test", Audience: "api.shop.test", TTL: 10 * time.Minute, Now: c.Now})
+}
+
+func newHS256(t *testing.T, c *clock) *JWT {
+	return newJWT(t, HS256, c, Key{ID: "hs-1", Material: secret})
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	c := &clock{now: time.Now()}
+	for algorithm, key := range map[string]Key{
+		HS256: {ID: "hs-1", Material: secret},
+		RS256: {ID: "rs-1", Material: privatePEM(t, rsaKey)},
+		EdDSA: {ID: "ed-1", Material: privatePEM(t, edKey)},
+	} {
+		j := newJWT(t, algorithm, c, key)
+		raw, err := j.Issue(testUser, "session-1")
+		if err != nil {
+			t.Fatalf("%s: Issue: %v", algorithm, err)
+		}
+		claims, err := j.Verify(raw)
+		if err != nil {
+			t.Fatalf("%s: Verify: %v", algorithm, err)
+		}
+		if !reflect.DeepEqual(claims.User, testUser) || claims.SessionID != "session-1" || claims.Subject != "7" || claims.Issuer != "shop.test" ||
+			len(claims.Audience) != 1 || claims.Audience[0] != "api.shop.test" ||
+			!claims.IssuedAt.Time.Equal(c.now.Truncate(time.Second)) ||
+			!claims.ExpiresAt.Time.Equal(c.now.Add(10*time.Minute).Truncate(time.Second)) {
+			t.Errorf("%s: unexpected claims %+v", algorithm, claims)
+		}
+
+		if again, err := j.Issue(testUser, "session-1"); err != nil || again == raw || claims.ID == "" {
+			t.Errorf("%s: tokens issued at the same moment are not unique: jti %q", algorithm, claims.ID)
+		}
+
+		header, _ := base64.RawURLEncoding.DecodeString(strings.Split(raw, ".")[0])
+		if !strings.Contains(string(header), `"kid":"`+key.ID+`"`) || !strings.Contains(string(header), `"alg":"`+algorithm+`"`) {
+			t.Errorf("%s: header %s", algorithm, header)
+		}
+	}
+}
+
+func TestVerifyExpiry(t *testing.T) {
+	c := &clock{now: time.Now()}
+	j := newHS256(t, c)
+	raw, err := j.Issue(testUser, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.now = c.now.Add(9 * time.Minute)
+	if _, err := j.Verify(raw); err != nil {
+		t.Errorf("token rejected before expiry: %v", err)
+	}
+	c.now = c.now.Add(2 * time.Minute)
+	if _, err := j.Verify(raw); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired token: got %v, want ErrExpired", err)
+	}
+
+	c.now = c.now.Add(-time.Hour)
+	if _, err := j.Verify(raw); !errors.Is(err, ErrInvalid) {
+		t.Errorf("token issued in the future: got %v, want ErrInvalid", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	c := &clock{now: time.Now()}
+	j := newHS256(t, c)
+	raw, err := j.Issue(testUser, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(raw, ".")
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	claims["user"].(map[string]interface{})["email"] = "attacker@mail.ru"
+	forged, _ := json.Marshal(claims)
+
+	signature := []byte(parts[2])
+	signature[0] ^= 1
+
+	for name, token := range map[string]string{
+		"changed payload":   parts[0] + "." + base64.RawURLEncoding.EncodeToString(forged) + "." + parts[2],
+		"changed signature": parts[0] + "." + parts[1] + "." + string(signature),
+		"no signature":      parts[0] + "." + parts[1] + ".",
+		"other secret":      mustIssue(t, newJWT(t, HS256, c, Key{ID: "hs-1", Material: []byte("another secret of 32 bytes long!")})),
+		"unknown kid":       mustIssue(t, newJWT(t, HS256, c, Key{ID: "hs-2", Material: secret})),
+		"garbage":           "not-a-token",
+	} {
+		if _, err := j.Verify(token); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: got %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func mustIssue(t *testing.T, j *JWT) string {
+	t.Helper()
+	raw, err := j.Issue(testUser, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestVerifyRejectsAlgorithmConfusion(t *testing.T) {
+	c := &clock{now: time.Now()}
+	rs := newJWT(t, RS256, c, Key{ID: "rs-1", Material: privatePEM(t, rsaKey)})
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "shop.test",
+			Audience:  jwt.ClaimStrings{"api.shop.test"},
+			Subject:   "7",
+			IssuedAt:  jwt.NewNumericDate(c.now),
+			ExpiresAt: jwt.NewNumericDate(c.now.Add(time.Minute)),
+		},
+		User: testUser,
+	}
+
+	hmacWithPublicKey := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hmacWithPublicKey.Header["kid"] = "rs-1"
+	forged, err := hmacWithPublicKey.SignedString(publicPEM(t, rsaKey.Public()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps256 := jwt.NewWithClaims(jwt.SigningMethodPS256, claims)
+	ps256.Header["kid"] = "rs-1"
+	otherRSA, err := ps256.SignedString(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{
+		"HS256 signed with the public key": forged,
+		"alg none":                         unsigned,
+		"PS256 with the same key":          otherRSA,
+	} {
+		if _, err := rs.Verify(token); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: got %v, want ErrInvalid", name, err)
+		}
+	}
+
+	if _, err := newHS256(t, c).Verify(mustIssue(t, rs)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("RS256 token accepted by the HS256 verifier: %v", err)
+	}
+}
+
+func TestVerifyChecksClaims(t *testing.T) {
+	c := &clock{now: time.Now()}
+	j := newHS256(t, c)
+
+	for name, change := range map[string]func(claims *Claims){
+		"other issuer":       func(claims *Claims) { claims.Issuer = "crm.example" },
+		"other audience":     func(claims *Claims) { claims.Audience = jwt.ClaimStrings{"render-farm"} },
+		"no audience":        func(claims *Claims) { claims.Audience = nil },
+		"no exp":             func(claims *Claims) { claims.ExpiresAt = nil },
+		"no iat":             func(claims *Claims) { claims.IssuedAt = nil },
+		"no subject":         func(claims *Claims) { claims.Subject = "" },
+		"subject of another": func(claims *Claims) { claims.Subject = "8" },
+	} {
+		claims := &Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "shop.test",
+				Audience:  jwt.ClaimStrings{"api.shop.test"},
+				Subject:   "7",
+				IssuedAt:  jwt.NewNumericDate(c.now),
+				ExpiresAt: jwt.NewNumericDate(c.now.Add(time.Minute)),
+			},
+			User: testUser,
+		}
+		change(claims)
+		raw, err := j.keys.sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := j.Verify(raw); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: got %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	c := &clock{now: time.Now()}
+	oldKey := Key{ID: "2024-01", Material: privatePEM(t, edKey)}
+	old := mustIssue(t, newJWT(t, EdDSA, c, oldKey))
+
+	newKey := mustEdKey()
+	rotated := newJWT(t, EdDSA, c,
+		Key{ID: "2024-06", Material: privatePEM(t, newKey)},
+		Key{ID: "2024-01", Material: publicPEM(t, edKey.Public())},
+	)
+	for name, raw := range map[string]string{"old key": old, "new key": mustIssue(t, rotated)} {
+		if _, err := rotated.Verify(raw); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	jwks := rotated.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].KeyID != "2024-06" || jwks.Keys[1].KeyID != "2024-01" {
+		t.Fatalf("unexpected JWKS: %+v", jwks)
+	}
+	x, _ := base64.RawURLEncoding.DecodeString(jwks.Keys[1].X)
+	if jwks.Keys[1].KeyType != "OKP" || jwks.Keys[1].Curve != "Ed25519" || !edKey.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+		t.Errorf("JWK does not describe the old key: %+v", jwks.Keys[1])
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	c := &clock{now: time.Now()}
+	rs := newJWT(t, RS256, c, Key{ID: "rs-1", Material: privatePEM(t, rsaKey)})
+	keys := rs.JWKS().Keys
+	if len(keys) != 1 || keys[0].KeyType != "RSA" || keys[0].Alg != RS256 || keys[0].Use != "sig" || keys[0].E != "AQAB" {
+		t.Fatalf("unexpected JWKS: %+v", keys)
+	}
+	n, _ := base64.RawURLEncoding.DecodeString(keys[0].N)
+	if string(n) != string(rsaKey.N.Bytes()) {
+		t.Errorf("modulus differs from the key")
+	}
+
+	data, err := json.Marshal(newHS256(t, c).JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"keys":[]}` {
+		t.Errorf("HS256 JWKS = %s, the secret must not be published", data)
+	}
+}
+
+func TestNewKeySetErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		algorithm string
+		keys      []Key
+	}{
+		"no keys":               {HS256, nil},
+		"empty secret":          {HS256, []Key{{ID: "1"}}},
+		"unknown algorithm":     {"HS512", []Key{{ID: "1", Material: secret}}},
+		"not PEM":               {RS256, []Key{{ID: "1", Material: secret}}},
+		"public key to sign":    {RS256, []Key{{ID: "1", Material: publicPEM(t, rsaKey.Public())}}},
+		"short RSA key":         {RS256, []Key{{ID: "1", Material: privatePEM(t, mustRSAKey(1024))}}},
+		"RSA key for EdDSA":     {EdDSA, []Key{{ID: "1", Material: privatePEM(t, rsaKey)}}},
+		"Ed25519 key for RS256": {RS256, []Key{{ID: "1", Material: privatePEM(t, edKey)}}},
+	} {
+		if _, err := NewKeySet(tc.algorithm, tc.keys); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"strings"
+	"testing"
+)
+
+/// Функция useRecorder подменяет глобальный TracerProvider на провайдер с экспортером в память \\\
+
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestInit(t *testing.T) {
+	shutdown, err := Init(context.Background(), Options{Exporter: ExporterNone})
+	if err != nil {
+		t.Fatalf("Init(none): %v", err)
+	}
+	if err = shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+
+	if _, err = Init(context.Background(), Options{Exporter: "zipkin"}); err == nil {
+		t.Error("Init with an unknown exporter: got nil error")
+	}
+}
+
+func TestStdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := newStdoutExporter(&buf)
+	if err != nil {
+		t.Fatalf("newStdoutExporter: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	_, span := provider.Tracer(instrumentationName).Start(context.Background(), "user.Service.Create")
+	span.End()
+	if err = provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"Name":"user.Service.Create"`) {
+		t.Errorf("stdout exporter output: %s", buf.String())
+	}
+}
+
+func TestQuerySpans(t *testing.T) {
+	recorder := useRecorder(t)
+
+	ctx, parent := Start(context.Background(), "user.Service.GetById")
+	for _, err := range []error{nil, pgx.ErrNoRows, fmt.Errorf("scan: %w", errors.New("connection reset"))} {
+		_, span := StartQuery(ctx, "find_user_by_id")
+		RecordError(span, err)
+		span.End()
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("got %d spans, want 4", len(spans))
+	}
+	wantStatus := []codes.Code{codes.Unset, codes.Unset, codes.Error}
+	for i, want := range wantStatus {
+		span := spans[i]
+		if span.Name() != "postgres.find_user_by_id" {
+			t.Errorf("span %d: got name %q", i, span.Name())
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %d is not a child of the service span", i)
+		}
+		if span.Status().Code != want {
+			t.Errorf("span %d: got status %v, want %v", i, span.Status().Code, want)
+		}
+	}
+}
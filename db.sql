@@ -1,15 +1,23 @@
 DROP TABLE IF EXISTS users;
 DROP TABLE IF EXISTS appeal;
 DROP TABLE IF EXISTS service;
+DROP TABLE IF EXISTS portfolio;
+DROP TABLE IF EXISTS rate_limit;
+DROP TABLE IF EXISTS rate_limit_lock;
+DROP TABLE IF EXISTS pending_registration;
+DROP TABLE IF EXISTS refresh_session;
 
 CREATE TABLE IF NOT EXISTS users (
  id             bigserial   primary key,
- email          text        not null unique,
+ email          text        not null,
  name           text        not null,
  surname        text        not null,
  password       text        not null
 );
 
+-- Адреса почты уникальны без учета регистра: Foo@Mail.ru и foo@mail.ru - один пользователь
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_idx ON users (lower(email));
+
 CREATE TABLE IF NOT EXISTS  appeal (
  id             bigserial   primary key,
  email          text        not null,
@@ -20,11 +28,69 @@ CREATE TABLE IF NOT EXISTS  appeal (
  document       text
 );
 
+-- Телефоны хранятся в формате E.164, по ним и по почте сотрудники ищут и объединяют обращения
+CREATE INDEX IF NOT EXISTS appeal_phone_number_idx ON appeal (phone_number);
+CREATE INDEX IF NOT EXISTS appeal_email_lower_idx ON appeal (lower(email));
+
 CREATE TABLE IF NOT EXISTS  service (
  id             bigserial   primary key,
  price          text        not null,
  description    text        not null,
- name_service   text        not null
+ name_service   text        not null,
+ image          text        not null,
+ position       integer     not null default 0
 );
 
+CREATE TABLE IF NOT EXISTS  portfolio (
+ id             bigserial   primary key,
+ title          text        not null,
+ description    text        not null,
+ image          text        not null,
+ position       integer     not null default 0
+);
+
+-- Страницы service.html и portfolio.html строятся сервером из этих таблиц, цены в валюте сайта
+INSERT INTO service (name_service, description, price, image, position) VALUES
+ ('Creation of 3d models', 'Detailed 3D models of furniture, decor and whole rooms', '5000', '1.jpg', 1),
+ ('Animation', 'Walkthrough videos that show the interior from every angle', '15000', '4.jpg', 2),
+ ('Interior visualization', 'Photorealistic renders of your future interior', '10000', '5.jpg', 3);
 
+INSERT INTO portfolio (title, description, image, position) VALUES
+ ('creatively designed', 'Lorem ipsum dolor sit', '1.jpg', 1),
+ ('creatively designed', 'Lorem ipsum dolor sit', '2.jpg', 2),
+ ('creatively designed', 'Lorem ipsum dolor sit', '3.jpg', 3),
+ ('creatively designed', 'Lorem ipsum dolor sit', '8.jpg', 4),
+ ('creatively designed', 'Lorem ipsum dolor sit', '10.jpg', 5),
+ ('creatively designed', 'Lorem ipsum dolor sit', '9.jpg', 6),
+ ('creatively designed', 'Lorem ipsum dolor sit', '5.jpg', 7);
+
+CREATE TABLE IF NOT EXISTS  rate_limit (
+ key            text        primary key,
+ count          integer     not null,
+ expires_at     timestamptz not null
+);
+
+CREATE TABLE IF NOT EXISTS  rate_limit_lock (
+ key            text        primary key,
+ locked_until   timestamptz not null
+);
+
+-- Регистрации, ожидающие подтверждения почты: пароль хранится bcrypt хэшем, код и токен ссылки - хэшами SHA-256
+CREATE TABLE IF NOT EXISTS  pending_registration (
+ email          text        primary key,
+ name           text        not null,
+ surname        text        not null,
+ password_hash  text        not null,
+ salt           bytea       not null,
+ code_hash      bytea       not null,
+ token_hash     bytea       not null unique,
+ expires_at     timestamptz not null,
+ attempts       integer     not null default 0
+);
+
+-- Сессии входа: хранится только хэш SHA-256 действующего токена обновления, каждое продление заменяет его новым
+CREATE TABLE IF NOT EXISTS  refresh_session (
+ session_id     text        primary key,
+ token_hash     bytea       not null,
+ expires_at     timestamptz not null
+);
@@ -0,0 +1,8 @@
+package public
+
+import "embed"
+
+/// Файлы сайта, встроенные в бинарный файл при сборке \\\
+
+//go:embed *
+var Files embed.FS